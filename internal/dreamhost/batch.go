@@ -0,0 +1,129 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// BatchResult reports the outcome of each record in a batch operation. Records appear in the order they were given.
+type BatchResult struct {
+	Succeeded []DNSRecordValue
+	Failed    []BatchFailure
+}
+
+// BatchFailure is a record from a batch operation that failed, along with the reason.
+type BatchFailure struct {
+	Record DNSRecordValue
+	Err    error
+}
+
+// Err returns the errors of all failed records joined together, or nil if every record succeeded.
+func (b BatchResult) Err() error {
+	var errs []error
+	for _, f := range b.Failed {
+		errs = append(errs, fmt.Errorf("%v record %v: %w", f.Record.RecordType, f.Record.Name, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// CreateRecords creates each of the records, continuing past failures so that the result reports every record's
+// outcome. Up to the client's batch concurrency (see WithBatchConcurrency) records are created at once. A uniqueId
+// string may optionally be provided for idempotency, from which a distinct id is derived for each record.
+//
+// Every record is validated before any is sent, and their names canonicalized as DreamHost lists them. If any record
+// is invalid, nothing is sent: the invalid records fail with their validation errors, and the others with an error
+// matching ErrBatchNotSubmitted.
+func (c *DNSClient) CreateRecords(records []DNSRecordValue, uniqueId string) BatchResult {
+	records, result, ok := c.validateBatch(records, c.addCmd)
+	if !ok {
+		return result
+	}
+	return c.runBatch(records, uniqueId, c.CreateRecord)
+}
+
+// DeleteRecords deletes each of the records, like CreateRecords. Records that don't exist count as deleted, as with
+// DeleteRecord.
+func (c *DNSClient) DeleteRecords(records []DNSRecordValue, uniqueId string) BatchResult {
+	records, result, ok := c.validateBatch(records, c.removeCmd)
+	if !ok {
+		return result
+	}
+	return c.runBatch(records, uniqueId, c.DeleteRecord)
+}
+
+// validateBatch checks every record with the checks the client makes before sending cmd about it, without sending
+// anything, and returns the records with canonical names. If any record is invalid, ok is false and the result reports
+// why for each record.
+func (c *DNSClient) validateBatch(records []DNSRecordValue, cmd string) (canonical []DNSRecordValue, result BatchResult, ok bool) {
+	errs := make([]error, len(records))
+	ok = true
+	for i, r := range records {
+		if errs[i] = c.checkAllowedZone(r); errs[i] == nil {
+			_, errs[i] = c.newGetRequest(context.Background(), c.apiKey, &r, cmd, "")
+		}
+		if errs[i] != nil {
+			ok = false
+			continue
+		}
+		r.Name = r.bareName()
+		canonical = append(canonical, r)
+	}
+	if ok {
+		return canonical, BatchResult{}, true
+	}
+
+	for i, r := range records {
+		err := errs[i]
+		if err == nil {
+			err = fmt.Errorf("%w: another record in the batch is invalid", ErrBatchNotSubmitted)
+		}
+		result.Failed = append(result.Failed, BatchFailure{r, err})
+	}
+	return nil, result, false
+}
+
+func (c *DNSClient) runBatch(records []DNSRecordValue, uniqueId string, op func(DNSRecordValue, string) error) BatchResult {
+	concurrency := c.batchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(records))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, r := range records {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, r DNSRecordValue) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = op(r, deriveStepId(uniqueId, strconv.Itoa(i)))
+		}(i, r)
+	}
+	wg.Wait()
+
+	var result BatchResult
+	for i, r := range records {
+		if errs[i] != nil {
+			result.Failed = append(result.Failed, BatchFailure{r, errs[i]})
+		} else {
+			result.Succeeded = append(result.Succeeded, r)
+		}
+	}
+	return result
+}
+
+// WithBatchConcurrency sets how many records batch operations such as CreateRecords and DeleteRecords process at once.
+// The default is one at a time.
+func WithBatchConcurrency(n int) Option {
+	return func(o *options) error {
+		if n < 1 {
+			return fmt.Errorf("batch concurrency must be at least 1, got %v", n)
+		}
+		o.client.batchConcurrency = n
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package dreamhost
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateRecords(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("value") == "badValue" {
+			return `{"result":"error","data":"invalid_record"}`
+		}
+		return `{"result":"success","data":"record_added"}`
+	})
+	defer svr.Close()
+
+	records := []DNSRecordValue{
+		{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "value1"},
+		{Name: "_acme-challenge.www.example.com", RecordType: "TXT", Value: "badValue"},
+		{Name: "_acme-challenge.api.example.com", RecordType: "TXT", Value: "value3"},
+	}
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithBatchConcurrency(2))
+	result := c.CreateRecords(records, "unique123")
+
+	if len(result.Succeeded) != 2 || result.Succeeded[0] != records[0] || result.Succeeded[1] != records[2] {
+		t.Errorf("Expected records 1 and 3 to succeed, got %+v", result.Succeeded)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Record != records[1] {
+		t.Fatalf("Expected record 2 to fail, got %+v", result.Failed)
+	}
+	if !errors.Is(result.Failed[0].Err, ErrNonSuccessResult) {
+		t.Errorf("Expected failure to be ErrNonSuccessResult, got %v", result.Failed[0].Err)
+	}
+	if !errors.Is(result.Err(), ErrNonSuccessResult) {
+		t.Errorf("Expected Err to wrap ErrNonSuccessResult, got %v", result.Err())
+	}
+
+	ids := map[string]bool{}
+	for _, r := range svr.requests {
+		ids[r.URL.Query().Get("unique_id")] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("Expected each record to use a distinct unique_id, got %v", ids)
+	}
+}
+
+func TestCreateRecordsValidatesBatchFirst(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	records := []DNSRecordValue{
+		{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "value1"},
+		{Name: "_acme-challenge.www.example.com", RecordType: "BOGUS", Value: "value2"},
+		{Name: "_acme-challenge.api.example.com", RecordType: "TXT", Value: "value3"},
+	}
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	for op, result := range map[string]BatchResult{"CreateRecords": c.CreateRecords(records, ""), "DeleteRecords": c.DeleteRecords(records, "")} {
+		if len(result.Succeeded) != 0 || len(result.Failed) != 3 {
+			t.Fatalf("%v: Expected every record to fail, got %+v", op, result)
+		}
+		if !errors.Is(result.Failed[1].Err, ErrUnsupportedRecordType) {
+			t.Errorf("%v: Expected record 2 to fail with ErrUnsupportedRecordType, got %v", op, result.Failed[1].Err)
+		}
+		for _, i := range []int{0, 2} {
+			if !errors.Is(result.Failed[i].Err, ErrBatchNotSubmitted) {
+				t.Errorf("%v: Expected record %v to fail with ErrBatchNotSubmitted, got %v", op, i+1, result.Failed[i].Err)
+			}
+		}
+	}
+	if len(svr.requests) != 0 {
+		t.Errorf("Expected no requests, got %v", svr.commands())
+	}
+}
+
+func TestCreateRecordsCanonicalizesNames(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	result := c.CreateRecords([]DNSRecordValue{{Name: "_acme-challenge.bücher.example.", RecordType: "TXT", Value: "testValue"}}, "")
+	if len(result.Succeeded) != 1 || result.Succeeded[0].Name != "_acme-challenge.xn--bcher-kva.example" {
+		t.Errorf("Expected the record to succeed with a canonical name, got %+v", result)
+	}
+}
+
+func TestCreateRecordsAllSucceed(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	result := c.CreateRecords([]DNSRecordValue{{Name: "example.com", RecordType: "TXT", Value: "testValue"}}, "")
+	if result.Err() != nil {
+		t.Errorf("Expected Err to be nil, got %v", result.Err())
+	}
+	if len(result.Succeeded) != 1 {
+		t.Errorf("Expected 1 record to succeed, got %v", len(result.Succeeded))
+	}
+}
+
+func TestCreateRecordsRespectsBatchConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	})
+	defer svr.Close()
+
+	records := make([]DNSRecordValue, 10)
+	for i := range records {
+		records[i] = DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	}
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithBatchConcurrency(3))
+	if err := c.CreateRecords(records, "").Err(); err != nil {
+		t.Errorf("Expected Err to be nil, got %v", err)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 requests in flight, got %v", maxInFlight)
+	}
+}
+
+func TestDeleteRecords(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		switch r.URL.Query().Get("value") {
+		case "absentValue":
+			return `{"result":"error","data":"no_such_value"}`
+		case "badValue":
+			return `{"result":"error","data":"invalid_record"}`
+		}
+		return `{"result":"success","data":"record_removed"}`
+	})
+	defer svr.Close()
+
+	records := []DNSRecordValue{
+		{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "value1"},
+		{Name: "_acme-challenge.www.example.com", RecordType: "TXT", Value: "absentValue"},
+		{Name: "_acme-challenge.api.example.com", RecordType: "TXT", Value: "badValue"},
+		{Name: "_acme-challenge.mail.example.com", RecordType: "TXT", Value: "value4"},
+	}
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithBatchConcurrency(2))
+	result := c.DeleteRecords(records, "")
+
+	if len(result.Succeeded) != 3 || result.Succeeded[0] != records[0] || result.Succeeded[1] != records[1] ||
+		result.Succeeded[2] != records[3] {
+		t.Errorf("Expected records 1, 2 and 4 to succeed, got %+v", result.Succeeded)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Record != records[2] {
+		t.Fatalf("Expected record 3 to fail, got %+v", result.Failed)
+	}
+	if !errors.Is(result.Err(), ErrNonSuccessResult) {
+		t.Errorf("Expected Err to wrap ErrNonSuccessResult, got %v", result.Err())
+	}
+	for _, cmd := range svr.commands() {
+		if !strings.HasPrefix(cmd, "dns-remove_record") {
+			t.Errorf("Expected only remove commands, got %v", cmd)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops the client sending requests while the API appears to be down. It opens after threshold
+// consecutive failures, rejecting requests for cooldown, and then lets a single probe request through: if the probe
+// succeeds the breaker closes, and if it fails the breaker opens again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may be sent, returning an error matching ErrCircuitOpen if not.
+func (b *circuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing {
+		return fmt.Errorf("%w: probe request in progress", ErrCircuitOpen)
+	}
+	if remaining := b.openedAt.Add(b.cooldown).Sub(now); remaining > 0 {
+		return fmt.Errorf("%w: retrying in %v", ErrCircuitOpen, remaining)
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a request that allow let through.
+func (b *circuitBreaker) record(now time.Time, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	if !isOutage(err) {
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
+
+// isOutage reports whether err suggests that the API is down: it couldn't be reached, or it responded with a 429 or 5xx
+// status. Other errors, such as an invalid record, an error result or a caller giving up, say nothing about the API's
+// health.
+func isOutage(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var urlErr *url.Error
+	return IsRetryable(err) || errors.As(err, &urlErr)
+}
+
+// checkCircuit returns an error matching ErrCircuitOpen if the client's circuit breaker, if any, is open.
+func (c *DNSClient) checkCircuit() error {
+	if c.breaker == nil {
+		return nil
+	}
+	return c.breaker.allow(c.clock.Now())
+}
+
+func (c *DNSClient) recordCircuit(err error) {
+	if c.breaker != nil {
+		c.breaker.record(c.clock.Now(), err)
+	}
+}
+
+// WithCircuitBreaker makes the client stop sending requests for cooldown after threshold consecutive requests fail,
+// e.g. during an API outage, failing them immediately with an error matching ErrCircuitOpen instead. A request fails
+// when, after any retries, the API can't be reached or responds with a 429 or 5xx status; other errors, such as invalid
+// records or error results from the API, don't count. After the cooldown, one request is let through to probe whether
+// the API has recovered. By default there is no circuit breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) error {
+		if threshold < 1 {
+			return fmt.Errorf("circuit breaker threshold must be positive, got %v", threshold)
+		}
+		if cooldown <= 0 {
+			return fmt.Errorf("circuit breaker cooldown must be positive, got %v", cooldown)
+		}
+		o.client.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+		return nil
+	}
+}
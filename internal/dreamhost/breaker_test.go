@@ -0,0 +1,112 @@
+package dreamhost
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	var calls int32
+	var healthy atomic.Bool
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(503)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(2, time.Second), WithCircuitBreaker(3, time.Minute))
+	c.clock = clk
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+
+	for i := 0; i < 3; i++ {
+		if err := c.CreateRecord(r, ""); !errors.Is(err, ErrUnexpectedStatus) {
+			t.Fatalf("Expected CreateRecord %v to return ErrUnexpectedStatus, got %v", i, err)
+		}
+	}
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected CreateRecord to return ErrCircuitOpen, got %v", err)
+	}
+	// Each failed call is retried once, and counts as one failure
+	if calls != 6 {
+		t.Errorf("Expected the open circuit to reject without a request, got %v requests", calls)
+	}
+
+	// A failed probe opens the circuit again
+	<-clk.After(time.Minute)
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("Expected the probe to return ErrUnexpectedStatus, got %v", err)
+	}
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected CreateRecord after a failed probe to return ErrCircuitOpen, got %v", err)
+	}
+
+	healthy.Store(true)
+	<-clk.After(time.Minute)
+	for i := 0; i < 2; i++ {
+		if err := c.CreateRecord(r, ""); err != nil {
+			t.Errorf("Expected CreateRecord %v after recovery not to return error, got %v", i, err)
+		}
+	}
+	if calls != 10 {
+		t.Errorf("Expected 10 requests, got %v", calls)
+	}
+}
+
+func TestCircuitBreakerIgnoresInvalidRequests(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithCircuitBreaker(1, time.Hour))
+	for _, r := range []DNSRecordValue{
+		{Name: "example.com", RecordType: "TXTT", Value: "testValue"},
+		{Name: "example.com", RecordType: "TXT", Value: ""},
+		{Name: "b\uffffcher.example", RecordType: "TXT", Value: "testValue"},
+	} {
+		if err := c.CreateRecord(r, ""); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected CreateRecord of invalid record %+v to return a validation error, got %v", r, err)
+		}
+	}
+
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord of a valid record not to return error, got %v", err)
+	}
+	if cmds := svr.commands(); len(cmds) != 1 {
+		t.Errorf("Expected the valid record to reach the server, got %v", cmds)
+	}
+}
+
+func TestCircuitBreakerAllowsOneProbe(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, cooldown: time.Minute}
+	now := time.Now()
+	b.record(now, retryable(errors.New("failed")))
+
+	now = now.Add(time.Minute)
+	if err := b.allow(now); err != nil {
+		t.Fatalf("Expected the first request after the cooldown to be allowed, got %v", err)
+	}
+	if err := b.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a second request during the probe to return ErrCircuitOpen, got %v", err)
+	}
+	b.record(now, nil)
+	if err := b.allow(now); err != nil {
+		t.Errorf("Expected requests after a successful probe to be allowed, got %v", err)
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidCircuitBreaker(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithCircuitBreaker(0, time.Minute)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+	if _, err := NewClientWithOptions("test123", WithCircuitBreaker(3, 0)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
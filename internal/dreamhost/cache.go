@@ -0,0 +1,92 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// recordsCache holds the most recent record list of each API key for a short time, so that lookups made in quick
+// succession, such as by GetRecord and ReplaceRecord, share a single dns-list_records request.
+type recordsCache struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	lists map[string]cachedList
+	// generation is incremented by every invalidation, so that a list fetched before a change isn't cached after it.
+	generation uint64
+}
+
+// cachedList is a record list held by recordsCache.
+type cachedList struct {
+	records []DNSRecord
+	fetched time.Time
+}
+
+// cachedRecords returns the record list of the given API key, from the cache if it's enabled and fresh.
+func (c *DNSClient) cachedRecords(ctx context.Context, key string) ([]DNSRecord, error) {
+	if c.cache == nil {
+		return c.listRecords(ctx, key)
+	}
+
+	c.cache.mu.Lock()
+	if list, ok := c.cache.lists[key]; ok && c.clock.Now().Sub(list.fetched) < c.cache.ttl {
+		records := append([]DNSRecord(nil), list.records...)
+		c.cache.mu.Unlock()
+		return records, nil
+	}
+	generation := c.cache.generation
+	c.cache.mu.Unlock()
+
+	records, err := c.listRecords(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if c.cache.generation == generation {
+		if c.cache.lists == nil {
+			c.cache.lists = make(map[string]cachedList)
+		}
+		c.cache.lists[key] = cachedList{records: append([]DNSRecord(nil), records...), fetched: c.clock.Now()}
+	}
+	return records, nil
+}
+
+// invalidateRecordsCache discards the cached record lists, if any, after a record may have been changed.
+func (c *DNSClient) invalidateRecordsCache() {
+	if c.cache == nil {
+		return
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	c.cache.lists = nil
+	c.cache.generation++
+}
+
+// WithListCoalescing makes concurrent record listings with the same API key, e.g. from many goroutines polling at once,
+// share a single dns-list_records request and its result. A shared request is bound to the context of the call that
+// started it, so cancelling that call fails the others waiting on it too.
+func WithListCoalescing() Option {
+	return func(o *options) error {
+		o.client.listCalls = &singleflight.Group{}
+		return nil
+	}
+}
+
+// WithRecordsCache makes lookups such as GetRecord reuse the record list for up to ttl rather than listing records
+// for every call. The cache is discarded whenever the client creates or deletes a record, but changes made by anything
+// else can go unnoticed for up to ttl. ListRecords always lists records afresh.
+func WithRecordsCache(ttl time.Duration) Option {
+	return func(o *options) error {
+		if ttl <= 0 {
+			return fmt.Errorf("records cache TTL must be positive, got %v", ttl)
+		}
+		o.client.cache = &recordsCache{ttl: ttl}
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package dreamhost
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newListingServer() *recordingServer {
+	return newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return listRecordsBody
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+}
+
+func TestRecordsCacheReusesListWithinTTL(t *testing.T) {
+	svr := newListingServer()
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRecordsCache(time.Minute))
+	c.clock = clk
+
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	for i := 0; i < 2; i++ {
+		if _, found, err := c.GetRecord(r); err != nil || !found {
+			t.Errorf("Expected GetRecord to find the record, got found %v and err %v", found, err)
+		}
+	}
+	if expected := "[dns-list_records]"; fmt.Sprint(svr.commands()) != expected {
+		t.Errorf("Expected commands %v, got %v", expected, svr.commands())
+	}
+
+	// Once the TTL has passed, the records are listed again
+	clk.After(time.Minute)
+	if _, _, err := c.GetRecord(r); err != nil {
+		t.Errorf("Expected GetRecord not to return error, got %v", err)
+	}
+	if expected := "[dns-list_records dns-list_records]"; fmt.Sprint(svr.commands()) != expected {
+		t.Errorf("Expected commands %v, got %v", expected, svr.commands())
+	}
+}
+
+func TestRecordsCacheIsPerKey(t *testing.T) {
+	svr := newListingServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRecordsCache(time.Minute),
+		WithKeyResolver(ZoneKeys{"other.org": "otherKey"}))
+	for _, name := range []string{"example.com", "other.org", "example.com", "other.org"} {
+		if _, _, err := c.GetRecord(DNSRecordValue{Name: name, RecordType: "TXT", Value: "testValue"}); err != nil {
+			t.Errorf("Expected GetRecord not to return error, got %v", err)
+		}
+	}
+
+	var keys []string
+	for _, r := range svr.requests {
+		keys = append(keys, r.URL.Query().Get("key"))
+	}
+	if expected := "[apikey123 otherKey]"; fmt.Sprint(keys) != expected {
+		t.Errorf("Expected records to be listed with keys %v, got %v", expected, keys)
+	}
+}
+
+func TestRecordsCacheIsInvalidatedByCreate(t *testing.T) {
+	svr := newListingServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRecordsCache(time.Minute))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	if _, _, err := c.GetRecord(r); err != nil {
+		t.Errorf("Expected GetRecord not to return error, got %v", err)
+	}
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "newValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if _, _, err := c.GetRecord(r); err != nil {
+		t.Errorf("Expected GetRecord not to return error, got %v", err)
+	}
+
+	expected := "[dns-list_records dns-add_record newValue dns-list_records]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestWithoutRecordsCacheListsEveryTime(t *testing.T) {
+	svr := newListingServer()
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.GetRecord(r); err != nil {
+			t.Errorf("Expected GetRecord not to return error, got %v", err)
+		}
+	}
+	if expected := "[dns-list_records dns-list_records]"; fmt.Sprint(svr.commands()) != expected {
+		t.Errorf("Expected commands %v, got %v", expected, svr.commands())
+	}
+}
+
+func TestWithRecordsCacheInvalidTTL(t *testing.T) {
+	if _, err := NewClientWithOptions("apikey123", WithRecordsCache(0)); err == nil {
+		t.Error("Expected NewClientWithOptions to return error, got nil")
+	}
+}
+
+func TestWithListCoalescing(t *testing.T) {
+	var calls int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		_, _ = fmt.Fprint(w, listRecordsBody)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithListCoalescing())
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records, err := c.ListRecords()
+			if err == nil && len(records) != 3 {
+				err = fmt.Errorf("got %v records", len(records))
+			}
+			errs <- err
+		}()
+	}
+
+	// Give every caller time to join the in-flight request before it completes
+	<-started
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected ListRecords not to return error, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 request, got %v", n)
+	}
+}
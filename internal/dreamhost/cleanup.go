@@ -0,0 +1,75 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const challengeLabel = "_acme-challenge"
+
+// recordKey identifies a record by name, type and value, ignoring case and any trailing dot in the name, and with the
+// value as the client sends it.
+type recordKey struct {
+	name       string
+	recordType string
+	value      string
+}
+
+func keyOf(r DNSRecordValue, keepTXTQuotes bool) recordKey {
+	return recordKey{strings.ToLower(r.bareName()), r.RecordType, r.sentValue(keepTXTQuotes)}
+}
+
+// noteCreated records when r was created by this client, for CleanupOrphanedChallengeRecords' age filter.
+func (c *DNSClient) noteCreated(r DNSRecordValue) {
+	c.created.Store(keyOf(r, c.keepTXTQuotes), c.clock.Now())
+}
+
+func (c *DNSClient) noteDeleted(r DNSRecordValue) {
+	c.created.Delete(keyOf(r, c.keepTXTQuotes))
+}
+
+// createdWithin reports whether this client created r less than d ago.
+func (c *DNSClient) createdWithin(r DNSRecordValue, d time.Duration) bool {
+	createdAt, ok := c.created.Load(keyOf(r, c.keepTXTQuotes))
+	return ok && c.clock.Now().Sub(createdAt.(time.Time)) < d
+}
+
+// CleanupOrphanedChallengeRecords deletes the ACME challenge records left behind in zone, e.g. by a crash or an
+// aborted issuance: the editable TXT records named _acme-challenge or _acme-challenge.<subdomain> in the zone. With
+// WithOwnershipMarker, only records carrying the marker are deleted.
+//
+// The API doesn't report when records were created, so the olderThan filter is best effort: it only spares records
+// that this client created less than olderThan ago. Records created by another process, or before this client was
+// created, are deleted regardless of their age, so avoid running this while other issuances are in progress.
+func (c *DNSClient) CleanupOrphanedChallengeRecords(ctx context.Context, zone string, olderThan time.Duration) (BatchResult, error) {
+	records, err := c.listRecordsForZone(ctx, zone)
+	if err != nil {
+		return BatchResult{}, fmt.Errorf("failed to list records: %w", err)
+	}
+
+	var orphans []DNSRecordValue
+	for _, record := range records {
+		name := normalizeName(record.Record)
+		if record.Type != "TXT" || !record.Editable {
+			continue
+		}
+		if c.ownerMarker != "" && !c.owns(record) {
+			continue
+		}
+		if name != challengeLabel && !strings.HasPrefix(name, challengeLabel+".") {
+			continue
+		}
+
+		r := storedValue(record)
+		if c.createdWithin(r, olderThan) {
+			continue
+		}
+		orphans = append(orphans, r)
+	}
+
+	return c.runBatch(orphans, "", func(r DNSRecordValue, uniqueId string) error {
+		return c.DeleteRecordContext(ctx, r, uniqueId)
+	}), nil
+}
@@ -0,0 +1,111 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const orphansListBody = `{
+	"data": [
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.com","type":"TXT","value":"orphan1","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.www.example.com","type":"TXT","value":"orphan2","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"example.com","type":"TXT","value":"v=spf1 -all","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge-not.example.com","type":"TXT","value":"other","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.com","type":"CNAME","value":"acme.example.net.","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"0","record":"_acme-challenge.example.com","type":"TXT","value":"system","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.org","type":"TXT","value":"otherZone","zone":"example.org"}
+	],
+	"result": "success"
+}`
+
+func TestCleanupOrphanedChallengeRecords(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return orphansListBody
+		}
+		return `{"result":"success","data":"record_removed"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	result, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("Expected CleanupOrphanedChallengeRecords not to return error, got %v", err)
+	}
+	if len(result.Succeeded) != 2 || result.Err() != nil {
+		t.Errorf("Expected 2 records to be deleted, got %+v", result)
+	}
+
+	expected := "[dns-list_records dns-remove_record orphan1 dns-remove_record orphan2]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestCleanupOrphanedChallengeRecordsDeletesQuotedValue(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return quotedListBody
+		}
+		return `{"result":"success","data":"record_removed"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", time.Hour); err != nil {
+		t.Fatalf("Expected CleanupOrphanedChallengeRecords not to return error, got %v", err)
+	}
+
+	expected := `[dns-list_records dns-remove_record "abc"]`
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestCleanupOrphanedChallengeRecordsSparesRecentRecords(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return orphansListBody
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	c.clock = clk
+	if err := c.CreateRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "orphan1"}, ""); err != nil {
+		t.Fatalf("Expected CreateRecord not to return error, got %v", err)
+	}
+
+	if _, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", time.Hour); err != nil {
+		t.Fatalf("Expected CleanupOrphanedChallengeRecords not to return error, got %v", err)
+	}
+	expected := "[dns-add_record orphan1 dns-list_records dns-remove_record orphan2]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+
+	// Once it's older than the threshold, the record created by this client is cleaned up too
+	clk.After(time.Hour)
+	if _, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", time.Hour); err != nil {
+		t.Fatalf("Expected CleanupOrphanedChallengeRecords not to return error, got %v", err)
+	}
+	expected = "[dns-add_record orphan1 dns-list_records dns-remove_record orphan2 dns-list_records dns-remove_record orphan1 dns-remove_record orphan2]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestCleanupOrphanedChallengeRecordsListFails(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":"invalid_api_key"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", 0); err == nil {
+		t.Error("Expected CleanupOrphanedChallengeRecords to return error, got nil")
+	}
+}
@@ -0,0 +1,21 @@
+package dreamhost
+
+import "time"
+
+// clock abstracts the passage of time for retries and rate limiting, so that tests can control it instead of sleeping.
+type clock interface {
+	Now() time.Time
+	// After returns a channel that receives the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,160 @@
+package dreamhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose time only moves when something waits on it, which then returns immediately. It records
+// each wait, so tests can check schedules without sleeping.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.waits = append(f.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+	return ch
+}
+
+func TestRetryBackoffSchedule(t *testing.T) {
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer svr.Close()
+
+	base := time.Second
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(4, base))
+	c.clock = clk
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+		t.Error("Expected CreateRecord to return error, got nil")
+	}
+
+	if calls != 4 || len(clk.waits) != 3 {
+		t.Fatalf("Expected 4 requests with 3 waits in between, got %v requests and waits %v", calls, clk.waits)
+	}
+	for i, wait := range clk.waits {
+		max := base << i
+		if wait < max/2 || wait > max {
+			t.Errorf("Expected wait %v to be within [%v, %v], got %v", i+1, max/2, max, wait)
+		}
+	}
+}
+
+// scheduleBackoff waits the delays in order, repeating the last one.
+type scheduleBackoff []time.Duration
+
+func (b scheduleBackoff) NextDelay(attempt int) time.Duration {
+	return b[min(attempt, len(b))-1]
+}
+
+func TestWithBackoffSchedules(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		min     []time.Duration
+		max     []time.Duration
+	}{
+		{"constant", ConstantBackoff{2 * time.Second},
+			[]time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+			[]time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second}},
+		{"exponential", ExponentialBackoff{time.Second},
+			[]time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"custom", scheduleBackoff{time.Second, 5 * time.Second},
+			[]time.Duration{time.Second, 5 * time.Second, 5 * time.Second},
+			[]time.Duration{time.Second, 5 * time.Second, 5 * time.Second}},
+	}
+	for _, test := range tests {
+		var calls int32
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(503)
+		}))
+
+		clk := newFakeClock()
+		// The base delay set by WithRetry only applies to the default backoff
+		c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(4, time.Minute), WithBackoff(test.backoff))
+		c.clock = clk
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+			t.Errorf("%v: Expected CreateRecord to return error, got nil", test.name)
+		}
+		svr.Close()
+
+		if calls != 4 || len(clk.waits) != 3 {
+			t.Errorf("%v: Expected 4 requests with 3 waits in between, got %v requests and waits %v", test.name, calls, clk.waits)
+			continue
+		}
+		for i, wait := range clk.waits {
+			if wait < test.min[i] || wait > test.max[i] {
+				t.Errorf("%v: Expected wait %v to be within [%v, %v], got %v", test.name, i+1, test.min[i], test.max[i], wait)
+			}
+		}
+	}
+}
+
+func TestRetryAfterDateUsesClock(t *testing.T) {
+	clk := newFakeClock()
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", clk.Now().Add(7*time.Second).Format(http.TimeFormat))
+			w.WriteHeader(429)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(2, time.Hour))
+	c.clock = clk
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if len(clk.waits) != 1 || clk.waits[0] != 7*time.Second {
+		t.Errorf("Expected a single wait of 7s, got %v", clk.waits)
+	}
+}
+
+func TestRateLimitScheduleUsesClock(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRateLimit(0.5, 1))
+	c.clock = clk
+	for i := 0; i < 3; i++ {
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+	}
+
+	// The first request uses the burst, and each later one waits for a token at 0.5/s
+	expected := []time.Duration{2 * time.Second, 2 * time.Second}
+	if len(clk.waits) != len(expected) || clk.waits[0] != expected[0] || clk.waits[1] != expected[1] {
+		t.Errorf("Expected waits %v, got %v", expected, clk.waits)
+	}
+}
@@ -0,0 +1,45 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+)
+
+// beginRequest registers a request as in flight, or returns ErrClosed if the client has been closed. Every successful
+// call must be paired with a call to endRequest.
+func (c *DNSClient) beginRequest() error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	c.inFlightRequests.Add(1)
+	return nil
+}
+
+func (c *DNSClient) endRequest() {
+	c.inFlightRequests.Done()
+}
+
+// Close stops the client from sending any further requests, which fail with ErrClosed, and waits for the requests
+// already in flight to finish, or until ctx is done. It then closes the idle connections of the http.Client. Closing a
+// client more than once is harmless.
+func (c *DNSClient) Close(ctx context.Context) error {
+	c.closeMu.Lock()
+	c.closed = true
+	c.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inFlightRequests.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return fmt.Errorf("requests still in flight: %w", ctx.Err())
+	}
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,102 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCloseDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL))
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+
+	slowErr := make(chan error, 1)
+	go func() { slowErr <- c.CreateRecord(r, "") }()
+	<-started
+
+	closeErr := make(chan error, 1)
+	go func() { closeErr <- c.Close(context.Background()) }()
+
+	// Wait for Close to mark the client closed, then check that new requests are rejected. Probing with requests
+	// before then could send one, which would block behind the in-flight request.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.closeMu.Lock()
+		closed := c.closed
+		c.closeMu.Unlock()
+		if closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected Close to mark the client closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.DeleteRecord(r, ""); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected DeleteRecord to return ErrClosed, got %v", err)
+	}
+
+	select {
+	case err := <-closeErr:
+		t.Fatalf("Expected Close to wait for the in-flight request, returned %v", err)
+	default:
+	}
+
+	close(release)
+	if err := <-slowErr; err != nil {
+		t.Errorf("Expected the in-flight CreateRecord to complete, got %v", err)
+	}
+	if err := <-closeErr; err != nil {
+		t.Errorf("Expected Close not to return error, got %v", err)
+	}
+}
+
+func TestCloseGivesUpWhenContextIsDone(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer svr.Close()
+	defer close(release)
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRetry(1, 0))
+	go func() {
+		_ = c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to return context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestCloseRejectsDryRunRequests(t *testing.T) {
+	c, _ := NewClientWithOptions("apikey123", WithDryRun())
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Expected Close not to return error, got %v", err)
+	}
+
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected CreateRecord to return ErrClosed, got %v", err)
+	}
+	if err := c.DeleteRecord(r, ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected DeleteRecord to return ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package dreamhost
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// debugDump writes redacted dumps of requests and responses, for diagnosing the API's behaviour.
+type debugDump struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// dumpRequest writes req, including any body, to the debug dump if one is configured.
+func (c *DNSClient) dumpRequest(req *http.Request) {
+	if c.debug == nil {
+		return
+	}
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		c.writeDump(fmt.Sprintf("failed to dump request: %v\n\n", err))
+		return
+	}
+	c.writeDump(string(dump) + "\n\n")
+}
+
+// dumpResponse writes the status line and headers of resp, followed by body if it has been read, to the debug dump if
+// one is configured. The body is passed separately so that dumping it doesn't bypass the response size limit.
+func (c *DNSClient) dumpResponse(resp *http.Response, body []byte) {
+	if c.debug == nil {
+		return
+	}
+	dump, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		c.writeDump(fmt.Sprintf("failed to dump response: %v\n\n", err))
+		return
+	}
+	c.writeDump(string(dump) + string(body) + "\n\n")
+}
+
+func (c *DNSClient) writeDump(s string) {
+	c.debug.mu.Lock()
+	defer c.debug.mu.Unlock()
+	_, _ = io.WriteString(c.debug.w, c.redact(s))
+}
+
+// WithDebugDump writes every request the client sends and every response it receives to w, with the API key
+// redacted. Dumps of concurrent requests don't interleave. By default nothing is dumped.
+func WithDebugDump(w io.Writer) Option {
+	return func(o *options) error {
+		if w == nil {
+			o.client.debug = nil
+		} else {
+			o.client.debug = &debugDump{w: w}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package dreamhost
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWithDebugDump(t *testing.T) {
+	for _, usePOST := range []bool{false, true} {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+
+		var buf bytes.Buffer
+		c, _ := NewClientWithOptions("s3cr3tKey", WithBaseURL(svr.URL), WithDebugDump(&buf))
+		c.usePOST = usePOST
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+
+		dump := buf.String()
+		for _, expected := range []string{"cmd=dns-add_record", "200 OK", `"data":"record_added"`} {
+			if !strings.Contains(dump, expected) {
+				t.Errorf("Expected dump (POST %v) to contain %v, got %v", usePOST, expected, dump)
+			}
+		}
+		if strings.Contains(dump, "s3cr3tKey") {
+			t.Errorf("Expected dump (POST %v) not to contain the API key, got %v", usePOST, dump)
+		}
+		svr.Close()
+	}
+}
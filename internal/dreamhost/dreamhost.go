@@ -1,61 +1,180 @@
 package dreamhost
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/idna"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
-const agentString = "cert-manager-webhook-dreamhost/0.1"
+// Version is the version of the webhook reported in the default User-Agent. It can be set at build time with
+// -ldflags "-X github.com/nprzy/cert-manager-webhook-dreamhost/internal/dreamhost.Version=1.2.3".
+var Version = "0.1"
+
+var agentString = "cert-manager-webhook-dreamhost/" + Version
+
 const dreamhostBaseUrl = "https://api.dreamhost.com/"
 
+// The API commands the client sends. The add and remove commands can be overridden with WithAddCommand and
+// WithRemoveCommand.
+const (
+	addRecordCmd    = "dns-add_record"
+	removeRecordCmd = "dns-remove_record"
+	listRecordsCmd  = "dns-list_records"
+	listCommandsCmd = "api-list_accessible_cmds"
+)
+
+// defaultResponseSizeLimit bounds how much of a response body is read, unless overridden with WithResponseSizeLimit.
+const defaultResponseSizeLimit = 10 << 20
+
+// responseFormat ties the format requested from the API with the format parameter and the Accept header to the decoder
+// for its responses, so that the three can't disagree.
+type responseFormat struct {
+	name      string
+	mediaType string
+	unmarshal func(data []byte, v any) error
+}
+
+// jsonFormat is the response format used by default, and the only one the client currently supports.
+var jsonFormat = responseFormat{name: "json", mediaType: "application/json", unmarshal: json.Unmarshal}
+
+// maxBodySnippet bounds how much of an unparseable response body is included in the error.
+const maxBodySnippet = 512
+
 // DNSClient is a client for creating and deleting DNS records using the Dreamhost DNS API.
 //
 // References:
 //   - https://help.dreamhost.com/hc/en-us/articles/4407354972692-Connecting-to-the-DreamHost-API
 //   - https://help.dreamhost.com/hc/en-us/articles/217555707-DNS-API-commands
 type DNSClient struct {
-	apiKey  string
-	client  *http.Client
-	BaseURL *url.URL
-}
+	apiKey    string
+	client    *http.Client
+	userAgent string
+	BaseURL   *url.URL
 
-func NewClient(apiKey string, httpClient *http.Client, baseUrl string) (*DNSClient, error) {
-	if apiKey == "" {
-		return nil, errors.New("empty apiKey")
-	}
-	if httpClient == nil {
-		httpClient = &http.Client{
-			// There is no timeout by default.
-			Timeout: time.Second * 15,
-		}
-	}
-	if baseUrl == "" {
-		baseUrl = dreamhostBaseUrl
-	}
+	idempotencyKeyHeader string
+	responseHeaderHook   func(http.Header)
+	readOnly             bool
+	usePOST              bool
 
-	apiUrl, err := url.Parse(baseUrl)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
+	retryAttempts  int
+	retryBaseDelay time.Duration
+	retryMalformed bool
+	dataClassifier DataClassifier
+	rateLimiter    *rate.Limiter
+
+	resolver     Resolver
+	pollInterval time.Duration
+
+	propagationWarnThreshold time.Duration
+
+	uniqueIds UniqueIDGenerator
+
+	batchConcurrency int
+
+	logger *slog.Logger
+
+	keyResolver KeyResolver
+
+	skipTypeValidation bool
+
+	keepTXTQuotes bool
+
+	allowEmptyValues bool
+
+	addCmd    string
+	removeCmd string
+
+	allowedZones []string
+
+	dryRun bool
+
+	strictUniqueId bool
+
+	checkEditable bool
+
+	maxRetryAfter time.Duration
+
+	backoff Backoff
+
+	clock clock
+
+	cache *recordsCache
 
-	return &DNSClient{apiKey, httpClient, apiUrl}, nil
+	listCalls *singleflight.Group
+
+	operationTimeout time.Duration
+
+	accountId string
+
+	format responseFormat
+
+	startup *startupDelay
+
+	stats Stats
+
+	responseSizeLimit int64
+
+	ownerMarker string
+
+	defaultTTL int
+
+	breaker *circuitBreaker
+
+	debug *debugDump
+
+	closeMu          sync.Mutex
+	closed           bool
+	inFlightRequests sync.WaitGroup
+
+	// created holds when this client created each record, keyed by recordKey.
+	created sync.Map
+
+	// inFlight holds a slot for each request being sent, if the number of concurrent requests is limited.
+	inFlight chan struct{}
 }
 
-func (c *DNSClient) prepareRequest(req *http.Request, cmd string, uniqueId string) {
-	req.Header.Set("User-Agent", agentString)
+// NewClient creates a DNSClient. A nil httpClient and an empty baseUrl select the defaults.
+//
+// Deprecated: Use NewClientWithOptions, which can be extended without breaking callers.
+func NewClient(apiKey string, httpClient *http.Client, baseUrl string) (*DNSClient, error) {
+	return NewClientWithOptions(apiKey, WithHTTPClient(httpClient), WithBaseURL(baseUrl))
+}
+
+func (c *DNSClient) prepareRequest(req *http.Request, apiKey string, cmd string, uniqueId string) {
+	req.Header.Set("User-Agent", c.userAgent)
+	// Asking for the format in the header as well as the query keeps proxies that negotiate content from returning
+	// something else.
+	req.Header.Set("Accept", c.format.mediaType)
 
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
+	q.Add("key", apiKey)
 	q.Add("cmd", cmd)
-	q.Add("format", "json")
+	q.Add("format", c.format.name)
+	if c.accountId != "" {
+		q.Add("account_id", c.accountId)
+	}
 	if uniqueId != "" {
 		q.Add("unique_id", uniqueId)
+		if c.idempotencyKeyHeader != "" {
+			req.Header.Set(c.idempotencyKeyHeader, uniqueId)
+		}
 	}
 	req.URL.RawQuery = q.Encode()
 }
@@ -65,8 +184,87 @@ func (c *DNSClient) prepareRequest(req *http.Request, cmd string, uniqueId strin
 // Example GET request:
 // https://api.dreamhost.com/?key=1A2B3C4D5E6F7G8H&cmd=dns-add_record&record=example.com&type=TXT&value=test123&format=json&unique_id=123456
 func (c *DNSClient) CreateRecord(r DNSRecordValue, uniqueId string) error {
-	resp, err := c.sendRequest(&r, "dns-add_record", uniqueId)
-	return suppressUniqueIdUsedErr(resp, err)
+	return c.CreateRecordContext(context.Background(), r, uniqueId)
+}
+
+// CreateRecordContext is like CreateRecord, but the request is bound to ctx.
+func (c *DNSClient) CreateRecordContext(ctx context.Context, r DNSRecordValue, uniqueId string) error {
+	_, err := c.createRecordResult(ctx, r, uniqueId)
+	return err
+}
+
+// CreateRecordResult is like CreateRecord, but also returns the API's response, e.g. to log its data, or to learn the
+// unique_id that was sent so that the record's deletion can be paired with it. If an error response was treated as
+// success, such as the record already existing, that response is returned.
+func (c *DNSClient) CreateRecordResult(r DNSRecordValue, uniqueId string) (*DreamhostResponse, error) {
+	return c.createRecordResult(context.Background(), r, uniqueId)
+}
+
+func (c *DNSClient) createRecordResult(ctx context.Context, r DNSRecordValue, uniqueId string) (*DreamhostResponse, error) {
+	if c.readOnly {
+		return nil, c.refuseReadOnly(ctx, "create", r)
+	}
+	if uniqueId == "" && c.uniqueIds != nil {
+		uniqueId = c.uniqueIds.UniqueID(r)
+	}
+	return c.createRecord(ctx, r, uniqueId)
+}
+
+// CreateOptions controls the idempotency of a single CreateRecordWithOptions call.
+type CreateOptions struct {
+	// UniqueID, if set, is sent as the unique_id.
+	UniqueID string
+	// Dedup derives a unique_id with DeriveUniqueID when UniqueID isn't set. When neither is set, no unique_id is sent,
+	// regardless of WithAutoUniqueID and WithUniqueIDGenerator.
+	Dedup bool
+}
+
+// CreateRecordWithOptions is like CreateRecord, but the unique_id is chosen by opts rather than by the client's
+// configuration, for callers that need different idempotency from the rest of the process.
+func (c *DNSClient) CreateRecordWithOptions(r DNSRecordValue, opts CreateOptions) error {
+	if c.readOnly {
+		return c.refuseReadOnly(context.Background(), "create", r)
+	}
+	uniqueId := opts.UniqueID
+	if uniqueId == "" && opts.Dedup {
+		uniqueId = DeriveUniqueID(r)
+	}
+	_, err := c.createRecord(context.Background(), r, uniqueId)
+	return err
+}
+
+func (c *DNSClient) createRecord(ctx context.Context, r DNSRecordValue, uniqueId string) (*DreamhostResponse, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	r, err := c.prepareAdd(r)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.sendRequest(ctx, &r, c.addCmd, uniqueId)
+	c.invalidateRecordsCache()
+	err = c.suppressUniqueIdUsedErr(err)
+	if errors.Is(err, ErrRecordExists) {
+		err = c.suppressRecordExistsErr(ctx, r, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.noteCreated(r)
+	return resp, nil
+}
+
+// prepareAdd checks that r may be created and fills in what the client adds to every record it creates: the ownership
+// marker and the default TTL.
+func (c *DNSClient) prepareAdd(r DNSRecordValue) (DNSRecordValue, error) {
+	if err := c.checkAllowedZone(r); err != nil {
+		return r, err
+	}
+	r.Comment = c.ownedComment(r)
+	if r.TTL == 0 {
+		r.TTL = c.defaultTTL
+	}
+	return r, nil
 }
 
 // DeleteRecord deletes a DNS record. A uniqueId string may optionally be provided for idempotency.
@@ -74,97 +272,686 @@ func (c *DNSClient) CreateRecord(r DNSRecordValue, uniqueId string) error {
 // Example GET request:
 // https://api.dreamhost.com/?key=1A2B3C4D5E6F7G8H&cmd=dns-remove_record&record=example.com&type=TXT&value=test123&format=json&unique_id=123456
 func (c *DNSClient) DeleteRecord(r DNSRecordValue, uniqueId string) error {
-	resp, err := c.sendRequest(&r, "dns-remove_record", uniqueId)
-	return suppressUniqueIdUsedErr(resp, err)
+	return c.DeleteRecordContext(context.Background(), r, uniqueId)
+}
+
+// DeleteRecordContext is like DeleteRecord, but the request is bound to ctx.
+func (c *DNSClient) DeleteRecordContext(ctx context.Context, r DNSRecordValue, uniqueId string) error {
+	_, err := c.deleteRecordResult(ctx, r, uniqueId)
+	return err
+}
+
+// DeleteRecordResult is like DeleteRecord, but also returns the API's response, e.g. to log its data. If an error
+// response was treated as success, such as the record not existing, that response is returned.
+func (c *DNSClient) DeleteRecordResult(r DNSRecordValue, uniqueId string) (*DreamhostResponse, error) {
+	return c.deleteRecordResult(context.Background(), r, uniqueId)
 }
 
-func (c *DNSClient) sendRequest(r *DNSRecordValue, cmd string, uniqueId string) (*DreamhostResponse, error) {
-	apiUrl := c.BaseURL.String()
+func (c *DNSClient) deleteRecordResult(ctx context.Context, r DNSRecordValue, uniqueId string) (*DreamhostResponse, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
 
-	// The URL needs to end with a trailing slash
-	if !strings.HasSuffix(apiUrl, "/") {
-		apiUrl += "/"
+	if c.readOnly {
+		return nil, c.refuseReadOnly(ctx, "delete", r)
 	}
+	if err := c.checkAllowedZone(r); err != nil {
+		return nil, err
+	}
+	if c.checkEditable {
+		record, found, err := c.GetRecordContext(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check whether %v record %v is editable: %w", r.RecordType, r.Name, err)
+		}
+		if found && !record.Editable {
+			return nil, fmt.Errorf("%w: %v record %v with value %v", ErrRecordNotEditable, r.RecordType, r.Name, r.Value)
+		}
+	}
+	resp, err := c.sendRequest(ctx, &r, c.removeCmd, uniqueId)
+	c.invalidateRecordsCache()
+	if err = suppressNoSuchRecordErr(c.suppressUniqueIdUsedErr(err)); err != nil {
+		return nil, err
+	}
+	c.noteDeleted(r)
+	return resp, nil
+}
+
+// ServerTime returns the DreamHost API server's current time, as reported by the Date header of a lightweight request.
+// Comparing it to the local time is useful when diagnosing clock skew.
+func (c *DNSClient) ServerTime() (time.Time, error) {
+	return c.ServerTimeContext(context.Background())
+}
+
+// ServerTimeContext is like ServerTime, but the request is bound to ctx.
+func (c *DNSClient) ServerTimeContext(ctx context.Context) (serverTime time.Time, err error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+	ctx, requestId := ensureRequestID(ctx)
+	start := time.Now()
+	defer func() {
+		err = withRequestID(err, requestId)
+		c.logRequest(ctx, slog.LevelDebug, listCommandsCmd, nil, start, err)
+		c.observeRequest(listCommandsCmd, start, err)
+	}()
 
-	req, err := http.NewRequest("GET", apiUrl, nil)
+	_, header, err := c.fetch(ctx, c.apiKey, nil, listCommandsCmd, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return time.Time{}, err
+	}
+
+	date := header.Get("Date")
+	if date == "" {
+		return time.Time{}, errors.New("dreamhost API response did not include a Date header")
+	}
+
+	serverTime, err = http.ParseTime(date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse Date header: %w", err)
+	}
+	return serverTime, nil
+}
+
+// HealthCheck verifies connectivity and the API key by issuing a read-only command, returning nil only if the API
+// responds with a successful result.
+func (c *DNSClient) HealthCheck(ctx context.Context) error {
+	if _, err := c.ListRecordsContext(ctx); err != nil {
+		return fmt.Errorf("dreamhost health check failed: %w", err)
+	}
+	return nil
+}
+
+func (c *DNSClient) apiUrl() string {
+	// Commands are sent to the base URL as a directory, so that a path prefix such as a gateway's is kept whether or not
+	// it ends with a slash. Any query in the base URL is kept too, and the command's parameters are added to it.
+	base := *c.BaseURL
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		base.RawPath = ""
+	}
+	return base.ResolveReference(&url.URL{Path: "./", RawQuery: base.RawQuery}).String()
+}
+
+// do sends req, which is bound to the context it was created with. If that context has a deadline, it takes precedence
+// over the http.Client's Timeout.
+func (c *DNSClient) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	client := c.client
+	if _, ok := ctx.Deadline(); ok && client.Timeout != 0 {
+		withoutTimeout := *client
+		withoutTimeout.Timeout = 0
+		client = &withoutTimeout
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("request aborted: %w", ctxErr)
+		}
+		return nil, retryable(c.redactErr(fmt.Errorf("HTTP request failed: %w", err)))
+	}
+	return resp, nil
+}
+
+func (c *DNSClient) sendRequest(ctx context.Context, r *DNSRecordValue, cmd string, uniqueId string) (resp *DreamhostResponse, err error) {
+	ctx, requestId := ensureRequestID(ctx)
+	start := time.Now()
+	defer func() {
+		err = withRequestID(err, requestId)
+		c.logRequest(ctx, slog.LevelInfo, cmd, r, start, err)
+		c.observeRequest(cmd, start, err)
+	}()
+
+	if c.dryRun {
+		// A closed client refuses commands even though a dry run wouldn't send them, so callers see the same behaviour
+		if err := c.beginRequest(); err != nil {
+			return nil, err
+		}
+		defer c.endRequest()
+		return c.dryRunRequest(ctx, r, cmd, uniqueId)
 	}
 
-	c.prepareRequest(req, cmd, uniqueId)
-	if err := r.addToReq(req); err != nil {
+	body, _, err := c.fetch(ctx, c.keyFor(r), r, cmd, uniqueId)
+	if err != nil {
 		return nil, err
 	}
+	// The result of a command is all its callers need, so an explicit No Content is a success. Commands whose data is
+	// needed, such as listing records, still fail to parse an empty body.
+	if body == nil {
+		return &DreamhostResponse{Result: "success", UniqueID: uniqueId}, nil
+	}
+	resp, err = c.parseResponse(cmd, body)
+	if resp != nil {
+		resp.UniqueID = uniqueId
+	}
+	return resp, err
+}
 
-	resp, err := c.client.Do(req)
+// dryRunRequest logs the request that would have been sent for a command, with the API key redacted, and reports
+// success without sending it.
+func (c *DNSClient) dryRunRequest(ctx context.Context, r *DNSRecordValue, cmd string, uniqueId string) (*DreamhostResponse, error) {
+	req, err := c.newRequest(ctx, c.keyFor(r), r, cmd, uniqueId)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, err
+	}
+
+	params := req.URL.RawQuery
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		form, _ := io.ReadAll(body)
+		params = string(form)
+	}
+	apiUrl := *req.URL
+	apiUrl.RawQuery = params
+
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "dry run: not sending dreamhost API command",
+		slog.String("cmd", cmd),
+		slog.String("method", req.Method),
+		slog.String("url", c.redact(apiUrl.String())),
+	)
+	return &DreamhostResponse{Result: "success", Data: json.RawMessage(`"dry_run"`), UniqueID: uniqueId}, nil
+}
+
+// fetch sends a command with the given API key, retrying transient failures, and returns the raw response body and
+// headers. The record r is optional. A 204 No Content response returns a nil body, whereas any other empty response
+// returns an empty, non-nil body.
+func (c *DNSClient) fetch(ctx context.Context, key string, r *DNSRecordValue, cmd string, uniqueId string) ([]byte, http.Header, error) {
+	if err := c.beginRequest(); err != nil {
+		return nil, nil, err
+	}
+	defer c.endRequest()
+
+	if err := c.waitForStartup(ctx); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkCircuit(); err != nil {
+		return nil, nil, err
+	}
+	var header http.Header
+	body, err := c.withRetry(ctx, func() ([]byte, error) {
+		body, h, err := c.fetchOnce(ctx, key, r, cmd, uniqueId)
+		if err == nil && body != nil {
+			if err := c.retryableBody(cmd, body); err != nil {
+				return nil, err
+			}
+		}
+		header = h
+		return body, err
+	})
+	c.recordCircuit(err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, header, nil
+}
+
+// retryableBody returns a retryable error for a response to cmd that is worth retrying: one that is malformed, with
+// WithRetryMalformedResponses, or an error response that the client's DataClassifier deems transient. Other responses
+// are left for the caller to parse.
+func (c *DNSClient) retryableBody(cmd string, body []byte) error {
+	// DefaultDataClassifier deems no response transient, so the body only needs decoding here if configured otherwise
+	if !c.retryMalformed && c.dataClassifier == nil {
+		return nil
+	}
+	resp, err := c.decodeResponse(body)
+	if err != nil {
+		if c.retryMalformed {
+			return retryable(err)
+		}
+		return nil
+	}
+	if resp.Result != "success" {
+		if apiErr := c.apiError(cmd, &resp); apiErr.class.Retryable {
+			return retryable(apiErr)
+		}
+	}
+	return nil
+}
+
+// newRequest builds the request for a command with the given API key. The record r is optional.
+func (c *DNSClient) newRequest(ctx context.Context, key string, r *DNSRecordValue, cmd string, uniqueId string) (*http.Request, error) {
+	req, err := c.newGetRequest(ctx, key, r, cmd, uniqueId)
+	if err != nil {
+		return nil, err
+	}
+	if c.usePOST {
+		return toPostRequest(req)
+	}
+	return req, nil
+}
+
+// BuildRequestURL returns the URL of the GET request the client would send for cmd about record r, without sending it,
+// e.g. to replay the command with curl. If redactKey is set, the API key is replaced with a placeholder. For the add
+// and remove commands, r is prepared as CreateRecord and DeleteRecord would, including the default TTL, ownership
+// marker, generated unique_id and allowed zones, but checks that need the API, such as WithEditableCheck, are skipped.
+func (c *DNSClient) BuildRequestURL(cmd string, r DNSRecordValue, uniqueId string, redactKey bool) (string, error) {
+	switch cmd {
+	case c.addCmd:
+		if uniqueId == "" && c.uniqueIds != nil {
+			uniqueId = c.uniqueIds.UniqueID(r)
+		}
+		var err error
+		if r, err = c.prepareAdd(r); err != nil {
+			return "", err
+		}
+	case c.removeCmd:
+		if err := c.checkAllowedZone(r); err != nil {
+			return "", err
+		}
+	}
+
+	req, err := c.newGetRequest(context.Background(), c.keyFor(&r), &r, cmd, uniqueId)
+	if err != nil {
+		return "", err
+	}
+	if redactKey {
+		return c.redact(req.URL.String()), nil
+	}
+	return req.URL.String(), nil
+}
+
+// newGetRequest builds the GET form of the request for a command, which newRequest converts to a POST if configured.
+func (c *DNSClient) newGetRequest(ctx context.Context, key string, r *DNSRecordValue, cmd string, uniqueId string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.apiUrl(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.prepareRequest(req, key, cmd, uniqueId)
+	if requestId, ok := RequestIDFromContext(ctx); ok {
+		req.Header.Set(requestIDHeader, requestId)
+	} else {
+		req.Header.Set(requestIDHeader, uuid.NewString())
+	}
+	if r != nil {
+		if err := r.addToReq(req, recordEncoding{keepTXTQuotes: c.keepTXTQuotes, allowEmptyValue: c.allowEmptyValues}); err != nil {
+			return nil, err
+		}
+		if !c.skipTypeValidation && !supportedRecordTypes[r.RecordType] {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedRecordType, r.RecordType)
+		}
+	}
+	return req, nil
+}
+
+// keyFor returns the API key to use for a command about record r, which is optional.
+func (c *DNSClient) keyFor(r *DNSRecordValue) string {
+	if r != nil && c.keyResolver != nil {
+		if key, ok := c.keyResolver.KeyFor(r.Name); ok {
+			return key
+		}
+	}
+	return c.apiKey
+}
+
+// toPostRequest moves the query parameters of a GET request into the form-encoded body of an equivalent POST request.
+func toPostRequest(req *http.Request) (*http.Request, error) {
+	form := req.URL.RawQuery
+	apiUrl := *req.URL
+	apiUrl.RawQuery = ""
+
+	post, err := http.NewRequestWithContext(req.Context(), "POST", apiUrl.String(), strings.NewReader(form))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	post.Header = req.Header.Clone()
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return post, nil
+}
+
+func (c *DNSClient) fetchOnce(ctx context.Context, key string, r *DNSRecordValue, cmd string, uniqueId string) ([]byte, http.Header, error) {
+	req, err := c.newRequest(ctx, key, r, cmd, uniqueId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			defer func() { <-c.inFlight }()
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("concurrency limit wait aborted: %w", ctx.Err())
+		}
+	}
+
+	c.dumpRequest(req)
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
 
+	c.runResponseHeaderHook(resp)
+
 	// The Dreamhost API seems to return a 200 status code, even when the response is an error.
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("dreamhost API returned unexpected status code %v", resp.StatusCode)
+		c.dumpResponse(resp, nil)
+		err := c.redactErr(&StatusError{Code: resp.StatusCode})
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, nil, retryableAfter(err, resp.Header.Get("Retry-After"), c.clock.Now())
+		}
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		c.dumpResponse(resp, nil)
+		return nil, resp.Header, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// Read one byte past the limit, so that an oversized body is reported rather than silently truncated
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.responseSizeLimit+1))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read HTTP body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read HTTP body: %w", err)
+	}
+	c.dumpResponse(resp, body)
+	if int64(len(body)) > c.responseSizeLimit {
+		return nil, nil, fmt.Errorf("%w: response exceeded size limit of %v bytes", ErrResponseTooLarge, c.responseSizeLimit)
 	}
+	return body, resp.Header, nil
+}
 
-	var apiResp DreamhostResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+func (c *DNSClient) parseResponse(cmd string, body []byte) (*DreamhostResponse, error) {
+	apiResp, err := c.decodeResponse(body)
+	if err != nil {
+		return nil, err
 	}
 
 	if apiResp.Result != "success" {
-		return &apiResp, fmt.Errorf("dreamhost API returned non-successful result: %v", apiResp)
+		return &apiResp, c.apiError(cmd, &apiResp)
 	}
 
 	return &apiResp, nil
 }
 
-func suppressUniqueIdUsedErr(resp *DreamhostResponse, err error) error {
+// apiError returns the error for a response to cmd whose result isn't "success", classified by the client's
+// DataClassifier.
+func (c *DNSClient) apiError(cmd string, resp *DreamhostResponse) *ApiError {
+	classifier := DefaultDataClassifier
+	if c.dataClassifier != nil {
+		classifier = c.dataClassifier
+	}
+	e := &ApiError{Result: resp.Result, Data: resp.DataString(), Reason: resp.Reason, Response: resp}
+	e.class, e.classified = classify(classifier, cmd, e), true
+	return e
+}
+
+// decodeResponse decodes a response body, which must have a non-empty result, without interpreting the result.
+func (c *DNSClient) decodeResponse(body []byte) (DreamhostResponse, error) {
+	var apiResp DreamhostResponse
+	if err := c.format.unmarshal(body, &apiResp); err != nil {
+		return apiResp, c.parseError(body, err)
+	}
+
+	if apiResp.Result == "" {
+		// Tell a response without a result, e.g. from something other than the API, from one with an empty result
+		var fields struct{ Result *string }
+		if err := c.format.unmarshal(body, &fields); err == nil && fields.Result != nil {
+			return apiResp, fmt.Errorf("%w: empty result field", ErrMalformedResponse)
+		}
+		return apiResp, fmt.Errorf("%w: missing result field", ErrMalformedResponse)
+	}
+	return apiResp, nil
+}
+
+// parseError reports that body couldn't be parsed, including the start of the body (with the API key redacted) since
+// it's often an HTML error page that explains what went wrong.
+func (c *DNSClient) parseError(body []byte, err error) error {
+	snippet := body
+	if len(snippet) > maxBodySnippet {
+		snippet = snippet[:maxBodySnippet]
+	}
+	return fmt.Errorf("failed to parse response: %w (body: %q)", err, c.redact(string(snippet)))
+}
+
+// waitForRateLimit blocks until the rate limiter, if any, allows another request, or ctx is done.
+func (c *DNSClient) waitForRateLimit(ctx context.Context) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("rate limiter wait aborted: %w", err)
+	}
+
+	now := c.clock.Now()
+	reservation := c.rateLimiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return errors.New("rate limiter burst is too small for a request")
+	}
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		reservation.CancelAt(c.clock.Now())
+		return fmt.Errorf("rate limiter wait aborted: %w", ctx.Err())
+	case <-c.clock.After(delay):
+		return nil
+	}
+}
+
+func (c *DNSClient) runResponseHeaderHook(resp *http.Response) {
+	if c.responseHeaderHook != nil {
+		c.responseHeaderHook(resp.Header.Clone())
+	}
+}
+
+// suppressRecordExistsErr suppresses err, a record_already_exists_remove_first error from creating r, if the existing
+// record holds exactly the value we wanted, since the caller's intent is then already fulfilled.
+func (c *DNSClient) suppressRecordExistsErr(ctx context.Context, r DNSRecordValue, err error) error {
+	_, found, getErr := c.GetRecordContext(ctx, r)
+	if getErr != nil {
+		return fmt.Errorf("%w (failed to check existing record: %v)", err, getErr)
+	}
+	if found {
+		return nil
+	}
+	return err
+}
+
+// DeriveUniqueID returns a unique_id that is deterministic for the record's name, type and value, so that repeated
+// creates of the same record are deduplicated by the API.
+func DeriveUniqueID(r DNSRecordValue) string {
+	sum := sha256.Sum256([]byte(r.Name + "\x00" + r.RecordType + "\x00" + r.Value))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *DNSClient) suppressUniqueIdUsedErr(err error) error {
 	// If the reason for the error is "unique_id_already_used", suppress the error because we assume that the caller's
 	// intent has been successfully fulfilled, albeit in a previous request.
-	if err != nil && resp != nil && resp.Data == "unique_id_already_used" {
+	if !c.strictUniqueId && errors.Is(err, ErrUniqueIDUsed) {
+		return nil
+	}
+	return err
+}
+
+func suppressNoSuchRecordErr(err error) error {
+	// Deleting a record that doesn't exist leaves the zone in the state the caller wanted, so for idempotent clean up
+	// this isn't an error.
+	if errors.Is(err, ErrNoSuchRecord) {
 		return nil
 	}
 	return err
 }
 
+// supportedRecordTypes are the record types that the DreamHost DNS API accepts.
+var supportedRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CAA":   true,
+	"CNAME": true,
+	"MX":    true,
+	"NAPTR": true,
+	"NS":    true,
+	"PTR":   true,
+	"SPF":   true,
+	"SRV":   true,
+	"TXT":   true,
+}
+
 // DNSRecordValue represents a single record name/value pair.
 type DNSRecordValue struct {
 	Name       string
 	RecordType string
 	Value      string
+	// Comment is optional, and can be used to tag records, e.g. to mark them as created by this webhook.
+	Comment string
+	// TTL is optional, and sets the record's TTL in seconds when it's created. When it's 0, the client's default TTL is
+	// used, if any. DreamHost may ignore it.
+	TTL int
+	// stored marks a value listed by the API, which is sent verbatim rather than as the client would encode a new one.
+	stored bool
+}
+
+// bareName returns Name without the trailing dot of a fully-qualified name, which DreamHost doesn't expect, and with
+// any Unicode labels in punycode, as DreamHost lists them. A name that can't be converted is returned as is, and is
+// rejected by addToReq.
+func (r *DNSRecordValue) bareName() string {
+	name := strings.TrimSuffix(r.Name, ".")
+	if ascii, err := asciiName(name); err == nil {
+		return ascii
+	}
+	return name
+}
+
+// NewSRVRecordValue returns the SRV record name, whose value DreamHost expects as "priority weight port target", e.g.
+// "10 5 5060 sip.example.com.".
+func NewSRVRecordValue(name string, priority, weight, port uint16, target string) DNSRecordValue {
+	return DNSRecordValue{
+		Name:       name,
+		RecordType: "SRV",
+		Value:      fmt.Sprintf("%d %d %d %s", priority, weight, port, target),
+	}
+}
+
+// idnaProfile maps internationalized names to the punycode DreamHost expects. It isn't strict about the characters
+// allowed in host names, so that labels like _acme-challenge and the * of a wildcard survive.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// asciiName returns name in its ASCII form, converting any Unicode labels to punycode. ASCII names are returned as is.
+func asciiName(name string) (string, error) {
+	for i := 0; i < len(name); i++ {
+		if name[i] >= utf8.RuneSelf {
+			ascii, err := idnaProfile.ToASCII(name)
+			if err != nil {
+				return "", fmt.Errorf("invalid internationalized name %q: %w", name, err)
+			}
+			return ascii, nil
+		}
+	}
+	return name, nil
 }
 
-func (r *DNSRecordValue) addToReq(req *http.Request) error {
-	if r.Name == "" {
+// unquoteTXT strips one layer of surrounding double quotes from a TXT value, which is how values appear in zone files
+// but which DreamHost would store as part of the value.
+func unquoteTXT(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// sentValue returns the value the client sends for r: for TXT records, without surrounding quotes unless keepTXTQuotes
+// is set or r is a stored value. Values are compared with existing records in this form, since it's how they were
+// stored.
+func (r *DNSRecordValue) sentValue(keepTXTQuotes bool) string {
+	if r.stored {
+		return r.Value
+	}
+	if r.RecordType == "TXT" && !keepTXTQuotes {
+		return unquoteTXT(r.Value)
+	}
+	return r.Value
+}
+
+// valueRequiredTypes are the record types that can never have an empty value.
+var valueRequiredTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"TXT":   true,
+}
+
+// recordEncoding controls how addToReq encodes a record.
+type recordEncoding struct {
+	// keepTXTQuotes sends TXT values as given, rather than stripping surrounding quotes.
+	keepTXTQuotes bool
+	// allowEmptyValue leaves it to the API to reject empty values, except for types in valueRequiredTypes.
+	allowEmptyValue bool
+}
+
+// addToReq adds the record to the request's query.
+func (r *DNSRecordValue) addToReq(req *http.Request, enc recordEncoding) error {
+	if strings.TrimSuffix(r.Name, ".") == "" {
 		return errors.New("DNSRecordValue.Name must not be empty")
 	}
-	// Allowing the DreamHost API to validate that the caller is requesting a valid RecordType.
+	name, err := asciiName(strings.TrimSuffix(r.Name, "."))
+	if err != nil {
+		return err
+	}
+	// Whether RecordType is one DreamHost supports is checked by the client, unless it's configured to leave that to
+	// the API.
 	if r.RecordType == "" {
 		return errors.New("DNSRecordValue.RecordType must not be empty")
 	}
-	if r.Value == "" {
+	value := r.sentValue(enc.keepTXTQuotes)
+	if value == "" && (!enc.allowEmptyValue || valueRequiredTypes[r.RecordType]) {
 		return errors.New("DNSRecordValue.Value must not be empty")
 	}
 
 	q := req.URL.Query()
-	q.Add("record", r.Name)
+	// url.Values escapes characters such as the * of a wildcard name, while leaving the _ of labels like
+	// _acme-challenge as is.
+	q.Add("record", name)
 	q.Add("type", r.RecordType)
-	q.Add("value", r.Value)
+	q.Add("value", value)
+	if r.Comment != "" {
+		q.Add("comment", r.Comment)
+	}
+	if r.TTL > 0 {
+		q.Add("ttl", strconv.Itoa(r.TTL))
+	}
 	req.URL.RawQuery = q.Encode()
 	return nil
 }
 
+// DreamhostResponse is a response from the API. Data is kept undecoded because its shape depends on the command: most
+// commands return a string, but list commands return an array, and some errors return structured data.
 type DreamhostResponse struct {
 	Result string
-	Data   string
+	Data   json.RawMessage
 	Reason string
+	// UniqueID is the unique_id sent with the command, if any, including one chosen by the client's
+	// UniqueIDGenerator. It isn't part of the API's response.
+	UniqueID string `json:"-"`
+}
+
+// DataString returns Data if it's a string. Any other JSON value is returned as its JSON text, and a missing or null
+// value as "".
+func (r *DreamhostResponse) DataString() string {
+	var s string
+	if err := json.Unmarshal(r.Data, &s); err == nil {
+		return s
+	}
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return ""
+	}
+	return string(r.Data)
+}
+
+// DataList returns the elements of Data, which must be an array.
+func (r *DreamhostResponse) DataList() ([]json.RawMessage, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(r.Data, &list); err != nil {
+		return nil, fmt.Errorf("data is not a list: %w", err)
+	}
+	return list, nil
 }
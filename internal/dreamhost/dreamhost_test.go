@@ -1,11 +1,19 @@
 package dreamhost
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 func TestNewClientWithMinimalArgs(t *testing.T) {
@@ -44,7 +52,7 @@ func TestNewClientWithInvalidUrl(t *testing.T) {
 func TestCreateRecord(t *testing.T) {
 	expectedCmd := "dns-add_record"
 	apiKey := "apikey123"
-	recordValue := DNSRecordValue{"example.com", "TXT", "testValue"}
+	recordValue := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
 
 	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
 		if r.UserAgent() != agentString {
@@ -89,7 +97,7 @@ func TestCreateRecord(t *testing.T) {
 func TestDeleteRecord(t *testing.T) {
 	expectedCmd := "dns-remove_record"
 	apiKey := "apikey123"
-	recordValue := DNSRecordValue{"example.com", "TXT", "testValue"}
+	recordValue := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
 
 	svr := mockHttpResponse(200, `{"data":"record_removed","result":"success"}`, func(r *http.Request) {
 		if r.UserAgent() != agentString {
@@ -131,6 +139,396 @@ func TestDeleteRecord(t *testing.T) {
 	}
 }
 
+func TestCreateRecordJoinsBaseURLPath(t *testing.T) {
+	tests := map[string]string{
+		"":                  "/",
+		"/":                 "/",
+		"/dreamhost":        "/dreamhost/",
+		"/dreamhost/":       "/dreamhost/",
+		"/gw/dreamhost?x=1": "/gw/dreamhost/",
+	}
+	for suffix, expectedPath := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			if r.URL.Path != expectedPath {
+				t.Errorf("Expected path for base URL suffix %q to be %v, got %v", suffix, expectedPath, r.URL.Path)
+			}
+			if r.URL.Query().Get("cmd") != "dns-add_record" {
+				t.Errorf("Expected cmd for base URL suffix %q to be dns-add_record, got %v", suffix, r.URL.Query().Get("cmd"))
+			}
+		})
+
+		c, _ := NewClient("apikey123", nil, svr.URL+suffix)
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord for base URL suffix %q not to return error, got %v", suffix, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordEncodesRecordNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		rawQuery string
+	}{
+		{"_acme-challenge.example.com.", "_acme-challenge.example.com", "record=_acme-challenge.example.com&"},
+		{"*.example.com", "*.example.com", "record=%2A.example.com&"},
+		{"_dmarc._domainkey.example.com", "_dmarc._domainkey.example.com", "record=_dmarc._domainkey.example.com&"},
+		{"_acme-challenge.bücher.example.", "_acme-challenge.xn--bcher-kva.example", "record=_acme-challenge.xn--bcher-kva.example&"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example", "record=xn--bcher-kva.example&"},
+	}
+	for _, test := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			if actual := r.URL.Query().Get("record"); actual != test.expected {
+				t.Errorf("Expected record for %v to be %v, got %v", test.name, test.expected, actual)
+			}
+			if !strings.Contains(r.URL.RawQuery, test.rawQuery) {
+				t.Errorf("Expected query for %v to contain %v, got %v", test.name, test.rawQuery, r.URL.RawQuery)
+			}
+		})
+
+		c, _ := NewClient("apikey123", nil, svr.URL)
+		if err := c.CreateRecord(DNSRecordValue{Name: test.name, RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord for %v not to return error, got %v", test.name, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordInvalidInternationalizedName(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "b\uffffcher.example", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil || !strings.Contains(err.Error(), "invalid internationalized name") {
+		t.Errorf("Expected CreateRecord to return an invalid name error, got %v", err)
+	}
+	if cmds := svr.commands(); len(cmds) != 0 {
+		t.Errorf("Expected no requests to be sent, got %v", cmds)
+	}
+}
+
+func TestCreateRecordWithOptions(t *testing.T) {
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	tests := []struct {
+		name       string
+		clientOpts []Option
+		opts       CreateOptions
+		expected   string
+	}{
+		{"explicit", nil, CreateOptions{UniqueID: "123"}, "123"},
+		{"explicit with dedup", nil, CreateOptions{UniqueID: "123", Dedup: true}, "123"},
+		{"dedup", nil, CreateOptions{Dedup: true}, DeriveUniqueID(r)},
+		{"none", nil, CreateOptions{}, ""},
+		{"none with auto unique id", []Option{WithAutoUniqueID()}, CreateOptions{}, ""},
+	}
+	for _, test := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(req *http.Request) {
+			q := req.URL.Query()
+			if test.expected == "" && q.Has("unique_id") {
+				t.Errorf("%v: Expected unique_id to not be present, got %v", test.name, q.Get("unique_id"))
+			}
+			if test.expected != "" && q.Get("unique_id") != test.expected {
+				t.Errorf("%v: Expected unique_id to be %v, got %v", test.name, test.expected, q.Get("unique_id"))
+			}
+		})
+
+		c, _ := NewClientWithOptions("apikey123", append([]Option{WithBaseURL(svr.URL)}, test.clientOpts...)...)
+		if err := c.CreateRecordWithOptions(r, test.opts); err != nil {
+			t.Errorf("%v: Expected CreateRecordWithOptions not to return error, got %v", test.name, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordStripsTXTQuotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		r        DNSRecordValue
+		expected string
+	}{
+		{"quoted", nil, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: `"testValue"`}, "testValue"},
+		{"unquoted", nil, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "testValue"},
+		{"doubly quoted", nil, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: `""testValue""`}, `"testValue"`},
+		{"opt out", []Option{WithLiteralTXTQuotes()}, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: `"testValue"`}, `"testValue"`},
+		{"not TXT", nil, DNSRecordValue{Name: "example.com", RecordType: "CNAME", Value: `"target"`}, `"target"`},
+	}
+	for _, test := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			if actual := r.URL.Query().Get("value"); actual != test.expected {
+				t.Errorf("%v: Expected value to be %v, got %v", test.name, test.expected, actual)
+			}
+		})
+
+		c, _ := NewClientWithOptions("apikey123", append([]Option{WithBaseURL(svr.URL)}, test.opts...)...)
+		if err := c.CreateRecord(test.r, ""); err != nil {
+			t.Errorf("%v: Expected CreateRecord not to return error, got %v", test.name, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordNoContent(t *testing.T) {
+	svr := mockHttpResponse(204, "", nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error for a 204 response, got %v", err)
+	}
+}
+
+func TestCreateRecordEmptyBody(t *testing.T) {
+	svr := mockHttpResponse(200, "", nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil || !strings.Contains(err.Error(), "failed to parse response") {
+		t.Errorf("Expected CreateRecord to return a parse error for an empty 200 response, got %v", err)
+	}
+}
+
+func TestListRecordsNoContent(t *testing.T) {
+	svr := mockHttpResponse(204, "", nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.ListRecords(); err == nil || !strings.Contains(err.Error(), "failed to parse response") {
+		t.Errorf("Expected ListRecords to return a parse error for a 204 response, got %v", err)
+	}
+}
+
+func TestCreateAndDeleteRecordResult(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-add_record" {
+			return `{"result":"success","data":"record_added"}`
+		}
+		return `{"result":"success","data":"record_removed"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	resp, err := c.CreateRecordResult(r, "")
+	if err != nil || resp == nil || resp.DataString() != "record_added" {
+		t.Errorf("Expected CreateRecordResult to return data record_added, got %+v and err %v", resp, err)
+	}
+	resp, err = c.DeleteRecordResult(r, "")
+	if err != nil || resp == nil || resp.DataString() != "record_removed" {
+		t.Errorf("Expected DeleteRecordResult to return data record_removed, got %+v and err %v", resp, err)
+	}
+}
+
+func TestCreateRecordResultReadOnly(t *testing.T) {
+	c, _ := NewClientWithOptions("apikey123", WithReadOnly(true))
+	resp, err := c.CreateRecordResult(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, ErrReadOnly) || resp != nil {
+		t.Errorf("Expected CreateRecordResult to return ErrReadOnly and no response, got %+v and err %v", resp, err)
+	}
+}
+
+func TestBuildRequestURL(t *testing.T) {
+	c, _ := NewClientWithOptions("s3cr3tKey", WithBaseURL("https://api.example.com/dreamhost/"))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+
+	for _, redactKey := range []bool{false, true} {
+		rawUrl, err := c.BuildRequestURL("dns-add_record", r, "123", redactKey)
+		if err != nil {
+			t.Fatalf("Expected BuildRequestURL not to return error, got %v", err)
+		}
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			t.Fatalf("Expected BuildRequestURL to return a valid URL, got %v", rawUrl)
+		}
+		if u.Host != "api.example.com" || u.Path != "/dreamhost/" {
+			t.Errorf("Expected URL to use the base URL, got %v", rawUrl)
+		}
+		expected := map[string]string{
+			"cmd":       "dns-add_record",
+			"record":    "_acme-challenge.example.com",
+			"type":      "TXT",
+			"value":     "testValue",
+			"format":    "json",
+			"unique_id": "123",
+		}
+		for k, v := range expected {
+			if actual := u.Query().Get(k); actual != v {
+				t.Errorf("Expected %v to be %v, got %v", k, v, actual)
+			}
+		}
+		if key := u.Query().Get("key"); redactKey == (key == "s3cr3tKey") {
+			t.Errorf("Expected key (redacted %v) to match, got %v", redactKey, key)
+		}
+	}
+}
+
+func TestBuildRequestURLPreparesRecord(t *testing.T) {
+	c, _ := NewClientWithOptions("apikey123", WithDefaultTTL(60), WithOwnershipMarker("owned"),
+		WithUniqueIDGenerator(ContentHashUniqueIDs{}))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+
+	rawUrl, err := c.BuildRequestURL("dns-add_record", r, "", true)
+	if err != nil {
+		t.Fatalf("Expected BuildRequestURL not to return error, got %v", err)
+	}
+	u, _ := url.Parse(rawUrl)
+	expected := map[string]string{"ttl": "60", "comment": "owned", "unique_id": DeriveUniqueID(r)}
+	for k, v := range expected {
+		if actual := u.Query().Get(k); actual != v {
+			t.Errorf("Expected %v to be %v, got %v", k, v, actual)
+		}
+	}
+}
+
+func TestBuildRequestURLValidatesRecord(t *testing.T) {
+	c, _ := NewClient("apikey123", nil, "")
+	if _, err := c.BuildRequestURL("dns-add_record", DNSRecordValue{Name: "example.com", RecordType: "BOGUS", Value: "v"}, "", true); !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("Expected BuildRequestURL to return ErrUnsupportedRecordType, got %v", err)
+	}
+}
+
+func TestNewSRVRecordValue(t *testing.T) {
+	r := NewSRVRecordValue("_sip._tcp.example.com", 10, 5, 5060, "sip.example.com.")
+	expected := DNSRecordValue{Name: "_sip._tcp.example.com", RecordType: "SRV", Value: "10 5 5060 sip.example.com."}
+	if r != expected {
+		t.Errorf("Expected record to be %+v, got %+v", expected, r)
+	}
+}
+
+func TestRequestsJSONFormatByDefault(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.URL.Query()["format"]; len(actual) != 1 || actual[0] != "json" {
+			t.Errorf("Expected format to be json, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestRequestsSendAcceptHeader(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.Header.Get("Accept"); actual != "application/json" {
+			t.Errorf("Expected Accept header to be application/json, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordUsesGetByDefault(t *testing.T) {
+	apiKey := "apikey123"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Expected method to be GET, got %v", r.Method)
+		}
+		if actual := r.URL.Query().Get("key"); actual != apiKey {
+			t.Errorf("Expected key to be %v, got %v", apiKey, actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient(apiKey, nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordWithPost(t *testing.T) {
+	apiKey := "apikey123"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected method to be POST, got %v", r.Method)
+		}
+		if strings.Contains(r.URL.String(), apiKey) {
+			t.Errorf("Expected URL not to contain the API key, got %v", r.URL.String())
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Expected form to parse, got %v", err)
+		}
+		if actual := r.PostForm.Get("key"); actual != apiKey {
+			t.Errorf("Expected key to be %v, got %v", apiKey, actual)
+		}
+		if actual := r.PostForm.Get("cmd"); actual != "dns-add_record" {
+			t.Errorf("Expected cmd to be dns-add_record, got %v", actual)
+		}
+		if actual := r.PostForm.Get("value"); actual != "testValue" {
+			t.Errorf("Expected value to be testValue, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions(apiKey, WithBaseURL(svr.URL), WithPOST())
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordWithComment(t *testing.T) {
+	comment := "cert-manager"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.URL.Query().Get("comment"); actual != comment {
+			t.Errorf("Expected comment to be %v, got %v", comment, actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue", Comment: comment}, "")
+	if err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordWithoutComment(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if q := r.URL.Query(); q.Has("comment") {
+			t.Errorf("Expected comment to not be present, got %v", q.Get("comment"))
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestDeleteRecordThatDoesNotExist(t *testing.T) {
+	for _, data := range []string{"no_such_record", "no_such_type", "no_such_value"} {
+		svr := mockHttpResponse(200, `{"result":"error","data":"`+data+`"}`, nil)
+
+		c, _ := NewClient("apikey123", nil, svr.URL)
+		if err := c.DeleteRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected DeleteRecord not to return error for %v, got %v", data, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordDoesNotSuppressNoSuchRecord(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":"no_such_record"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, ErrNoSuchRecord) {
+		t.Errorf("Expected CreateRecord to return ErrNoSuchRecord, got %v", err)
+	}
+}
+
 func TestCreateRecordWithUniqueId(t *testing.T) {
 	uniqueId := "unique123"
 
@@ -147,22 +545,104 @@ func TestCreateRecordWithUniqueId(t *testing.T) {
 		t.Errorf("expected NewClient err to be nil, got %v", err)
 	}
 
-	err = c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, uniqueId)
+	err = c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, uniqueId)
 	if err != nil {
 		t.Errorf("Expected CreateRecord not to return error, got %v", err)
 	}
 }
 
+func TestCreateRecordWithIdempotencyKeyHeader(t *testing.T) {
+	uniqueId := "unique123"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.Header.Get("Idempotency-Key"); actual != uniqueId {
+			t.Errorf("Expected Idempotency-Key header to be %v, got %v", uniqueId, actual)
+		}
+		if actual := r.URL.Query().Get("unique_id"); actual != uniqueId {
+			t.Errorf("Expected unique_id to be %v, got %v", uniqueId, actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithIdempotencyKeyHeader("Idempotency-Key"))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, uniqueId); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordWithoutIdempotencyKeyHeader(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.Header.Get("Idempotency-Key"); actual != "" {
+			t.Errorf("Expected Idempotency-Key header to be absent, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "unique123"); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestDeriveUniqueID(t *testing.T) {
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	if DeriveUniqueID(r) != DeriveUniqueID(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}) {
+		t.Error("Expected identical records to produce identical unique ids")
+	}
+
+	others := []DNSRecordValue{
+		{Name: "example.org", RecordType: "TXT", Value: "testValue"},
+		{Name: "example.com", RecordType: "A", Value: "testValue"},
+		{Name: "example.com", RecordType: "TXT", Value: "otherValue"},
+		{Name: "example.comTXT", RecordType: "", Value: "testValue"},
+	}
+	for _, other := range others {
+		if DeriveUniqueID(r) == DeriveUniqueID(other) {
+			t.Errorf("Expected %+v and %+v to produce different unique ids", r, other)
+		}
+	}
+}
+
+func TestCreateRecordWithAutoUniqueId(t *testing.T) {
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(req *http.Request) {
+		if actual := req.URL.Query().Get("unique_id"); actual != DeriveUniqueID(r) {
+			t.Errorf("Expected unique_id to be %v, got %v", DeriveUniqueID(r), actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithAutoUniqueID())
+	if err := c.CreateRecord(r, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
 func TestCreateRecordWithRepeatUniqueId(t *testing.T) {
 	svr := mockHttpResponse(200, `{"data":"unique_id_already_used","result":"error"}`, nil)
 	defer svr.Close()
 
 	c, _ := NewClient("apikey123", nil, svr.URL)
-	if err := c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, "unique123"); err != nil {
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "unique123"); err != nil {
 		t.Errorf("Expected CreateRecord not to return error, got %v", err)
 	}
 }
 
+func TestCreateRecordWithRepeatUniqueIdStrict(t *testing.T) {
+	svr := mockHttpResponse(200, `{"data":"unique_id_already_used","result":"error"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithStrictUniqueID())
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	if err := c.CreateRecord(r, "unique123"); !errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected CreateRecord to return ErrUniqueIDUsed, got %v", err)
+	}
+	if err := c.DeleteRecord(r, "unique123"); !errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected DeleteRecord to return ErrUniqueIDUsed, got %v", err)
+	}
+}
+
 func TestCreateRecord500Error(t *testing.T) {
 	expectedErrContent := "dreamhost API returned unexpected status code 500"
 
@@ -171,11 +651,21 @@ func TestCreateRecord500Error(t *testing.T) {
 	defer svr.Close()
 
 	c, _ := NewClient("testApiKey", nil, svr.URL)
-	if err := c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, ""); err == nil {
-		t.Error("Expected CreateRecord to return error, got nil")
+	c.retryAttempts = 1
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
 	} else if !strings.Contains(err.Error(), expectedErrContent) {
 		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
 	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected err to be a StatusError, got %#v", err)
+	}
+	if statusErr.Code != 500 {
+		t.Errorf("Expected status code 500, got %v", statusErr.Code)
+	}
 }
 
 func TestCreateRecordInvalidResponse(t *testing.T) {
@@ -186,10 +676,54 @@ func TestCreateRecordInvalidResponse(t *testing.T) {
 	defer svr.Close()
 
 	c, _ := NewClient("testApiKey", nil, svr.URL)
-	if err := c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, ""); err == nil {
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
 		t.Error("Expected CreateRecord to return error, got nil")
 	} else if !strings.Contains(err.Error(), expectedErrContent) {
 		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
+	} else if !strings.Contains(err.Error(), `"invalid"`) {
+		t.Errorf("Expected err to contain the response body, but was %v instead", err.Error())
+	}
+}
+
+func TestCreateRecordInvalidResponseSnippetIsTruncatedAndRedacted(t *testing.T) {
+	apiKey := "testApiKey"
+	body := "<html>" + apiKey + strings.Repeat("x", 2000) + "</html>"
+
+	svr := mockHttpResponse(200, body, nil)
+	defer svr.Close()
+
+	c, _ := NewClient(apiKey, nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
+	}
+	if !strings.Contains(err.Error(), "<html>REDACTED") {
+		t.Errorf("Expected err to contain the redacted start of the body, but was %v instead", err.Error())
+	}
+	if strings.Contains(err.Error(), "</html>") || strings.Contains(err.Error(), apiKey) {
+		t.Errorf("Expected err to contain a truncated, redacted snippet, but was %v instead", err.Error())
+	}
+}
+
+func TestCreateRecordResponseSizeLimit(t *testing.T) {
+	// Stream a body of 1 MiB, well over the limit
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := []byte(strings.Repeat(" ", 1024))
+		for i := 0; i < 1024; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithResponseSizeLimit(4096))
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Expected CreateRecord to return ErrResponseTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "response exceeded size limit of 4096 bytes") {
+		t.Errorf("Expected err to name the size limit, got %v", err)
 	}
 }
 
@@ -201,11 +735,94 @@ func TestCreateRecordConnectionError(t *testing.T) {
 	svr.Close()
 
 	c, _ := NewClient("testApiKey", nil, svr.URL)
-	if err := c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, ""); err == nil {
-		t.Error("Expected CreateRecord to return error, got nil")
+	c.retryAttempts = 1
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
 	} else if !strings.Contains(err.Error(), expectedErrContent) {
 		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
 	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("Expected err to wrap a *net.OpError, got %#v", err)
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("Expected err to wrap a *url.Error, got %#v", err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected connection error to be retryable, got %v", err)
+	}
+}
+
+func TestCreateRecordContextCancelled(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected CreateRecordContext to return context.Canceled, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "HTTP request failed") {
+		t.Errorf("Expected err not to be a generic HTTP failure, got %v", err)
+	}
+}
+
+func TestDeleteRecordContextDeadlineTakesPrecedence(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"result":"success","data":"record_removed"}`)
+	}))
+	defer svr.Close()
+
+	// The client timeout alone would fail the request, but the context allows it more time
+	c, _ := NewClient("testApiKey", &http.Client{Timeout: 10 * time.Millisecond}, svr.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.DeleteRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected DeleteRecordContext not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordRateLimited(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	c.rateLimiter = rate.NewLimiter(rate.Every(50*time.Millisecond), 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected rate limiter to delay requests for at least 100ms, took %v", elapsed)
+	}
+}
+
+func TestCreateRecordRateLimiterRespectsContext(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		t.Error("Expected no request to be made")
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	c.rateLimiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+	c.rateLimiter.Allow() // Use up the burst
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected CreateRecordContext to return context.Canceled, got %v", err)
+	}
 }
 
 func TestCreateRecordErrorResponse(t *testing.T) {
@@ -216,13 +833,114 @@ func TestCreateRecordErrorResponse(t *testing.T) {
 	defer svr.Close()
 
 	c, _ := NewClient("testApiKey", nil, svr.URL)
-	if err := c.CreateRecord(DNSRecordValue{"example.com", "TXT", "testValue"}, ""); err == nil {
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
 		t.Error("Expected CreateRecord to return error, got nil")
 	} else if !strings.Contains(err.Error(), expectedErrContent) {
 		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
 	}
 }
 
+func TestCreateRecordErrorResponseWithStructuredData(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":{"code":"internal_error"}}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected CreateRecord to return ApiError, got %v", err)
+	}
+	if apiErr.Data != `{"code":"internal_error"}` {
+		t.Errorf("Expected Data to be the JSON text of the structured data, got %v", apiErr.Data)
+	}
+}
+
+func TestDreamhostResponseDataString(t *testing.T) {
+	tests := map[string]string{
+		`"record_added"`: "record_added",
+		`["a","b"]`:      `["a","b"]`,
+		`null`:           "",
+		``:               "",
+	}
+	for data, expected := range tests {
+		resp := DreamhostResponse{Data: json.RawMessage(data)}
+		if got := resp.DataString(); got != expected {
+			t.Errorf("Expected DataString of %q to be %q, got %q", data, expected, got)
+		}
+	}
+}
+
+func TestDreamhostResponseDataList(t *testing.T) {
+	resp := DreamhostResponse{Data: json.RawMessage(`["a",{"b":1}]`)}
+	list, err := resp.DataList()
+	if err != nil {
+		t.Fatalf("Expected DataList not to return error, got %v", err)
+	}
+	if len(list) != 2 || string(list[0]) != `"a"` || string(list[1]) != `{"b":1}` {
+		t.Errorf("Expected DataList to return the array's elements, got %q", list)
+	}
+
+	resp = DreamhostResponse{Data: json.RawMessage(`"record_added"`)}
+	if _, err := resp.DataList(); err == nil {
+		t.Error("Expected DataList of a string to return error, got nil")
+	}
+}
+
+func TestCreateRecordMissingResult(t *testing.T) {
+	svr := mockHttpResponse(200, `{"data":"record_added"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("Expected CreateRecord to return ErrMalformedResponse, got %v", err)
+	}
+}
+
+func TestCreateRecordEmptyResult(t *testing.T) {
+	tests := map[string]string{
+		`{"data":"record_added"}`:             "missing result field",
+		`{"result":"","data":"record_added"}`: "empty result field",
+	}
+	for body, expected := range tests {
+		svr := mockHttpResponse(200, body, nil)
+
+		c, _ := NewClient("testApiKey", nil, svr.URL)
+		err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+		if !errors.Is(err, ErrMalformedResponse) || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected CreateRecord of %v to return ErrMalformedResponse with %q, got %v", body, expected, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestWithRetryMalformedResponses(t *testing.T) {
+	for _, retryMalformed := range []bool{false, true} {
+		attempts := 0
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				_, _ = fmt.Fprint(w, `<html>Bad Gateway</html>`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"result":"success","data":"record_added"}`)
+		}))
+
+		opts := []Option{WithBaseURL(svr.URL), WithRetry(2, time.Millisecond)}
+		if retryMalformed {
+			opts = append(opts, WithRetryMalformedResponses())
+		}
+		c, _ := NewClientWithOptions("testApiKey", opts...)
+		err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+		if retryMalformed && (err != nil || attempts != 2) {
+			t.Errorf("Expected CreateRecord to succeed on the second attempt, got %v after %v attempts", err, attempts)
+		}
+		if !retryMalformed && (err == nil || attempts != 1) {
+			t.Errorf("Expected CreateRecord to fail without retrying, got %v after %v attempts", err, attempts)
+		}
+		svr.Close()
+	}
+}
+
 func TestCreateRecordReturnsErrorWhenInputsAreMissing(t *testing.T) {
 	c, err := NewClient("test123", nil, "")
 	if err != nil {
@@ -230,9 +948,9 @@ func TestCreateRecordReturnsErrorWhenInputsAreMissing(t *testing.T) {
 	}
 
 	cases := map[DNSRecordValue]string{
-		DNSRecordValue{"", "TXT", "testValue"}:         "DNSRecordValue.Name must not be empty",
-		DNSRecordValue{"example.com", "", "testValue"}: "DNSRecordValue.RecordType must not be empty",
-		DNSRecordValue{"example.com", "TXT", ""}:       "DNSRecordValue.Value must not be empty",
+		DNSRecordValue{Name: "", RecordType: "TXT", Value: "testValue"}:         "DNSRecordValue.Name must not be empty",
+		DNSRecordValue{Name: "example.com", RecordType: "", Value: "testValue"}: "DNSRecordValue.RecordType must not be empty",
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: ""}:       "DNSRecordValue.Value must not be empty",
 	}
 
 	for record, expectedError := range cases {
@@ -243,6 +961,197 @@ func TestCreateRecordReturnsErrorWhenInputsAreMissing(t *testing.T) {
 	}
 }
 
+func TestWithEmptyValues(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	strict, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL))
+	relaxed, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithEmptyValues())
+
+	naptr := DNSRecordValue{Name: "example.com", RecordType: "NAPTR", Value: ""}
+	if err := strict.CreateRecord(naptr, ""); err == nil || !strings.Contains(err.Error(), "DNSRecordValue.Value must not be empty") {
+		t.Errorf("Expected CreateRecord of an empty NAPTR record to fail by default, got %v", err)
+	}
+	if err := relaxed.CreateRecord(naptr, ""); err != nil {
+		t.Errorf("Expected CreateRecord of an empty NAPTR record with WithEmptyValues not to return error, got %v", err)
+	}
+	for _, recordType := range []string{"TXT", "A", "AAAA", "CNAME"} {
+		err := relaxed.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: recordType, Value: ""}, "")
+		if err == nil || !strings.Contains(err.Error(), "DNSRecordValue.Value must not be empty") {
+			t.Errorf("Expected CreateRecord of an empty %v record to fail with WithEmptyValues, got %v", recordType, err)
+		}
+	}
+
+	if cmds := svr.commands(); len(cmds) != 1 || cmds[0] != "dns-add_record" {
+		t.Errorf("Expected only the relaxed NAPTR record to be sent, got %v", cmds)
+	}
+}
+
+func TestReadOnlyRefusesMutations(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		t.Errorf("Expected no request to be made, got cmd %v", r.URL.Query().Get("cmd"))
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithReadOnly(true))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected CreateRecord to return ErrReadOnly, got %v", err)
+	}
+	if err := c.DeleteRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected DeleteRecord to return ErrReadOnly, got %v", err)
+	}
+}
+
+func TestReadOnlyAllowsReads(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":[]}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithReadOnly(true))
+	if _, err := c.ServerTime(); err != nil {
+		t.Errorf("Expected ServerTime not to return error, got %v", err)
+	}
+}
+
+func TestResponseHeaderHook(t *testing.T) {
+	// The hook must see headers even when the request fails
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.WriteHeader(500)
+	}))
+	defer svr.Close()
+
+	var remaining string
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithResponseHeaderHook(func(h http.Header) {
+		remaining = h.Get("X-RateLimit-Remaining")
+	}))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+		t.Error("Expected CreateRecord to return error, got nil")
+	}
+	if remaining != "42" {
+		t.Errorf("Expected hook to see X-RateLimit-Remaining 42, got %v", remaining)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":[]}`, func(r *http.Request) {
+		if actual := r.URL.Query().Get("cmd"); actual != "dns-list_records" {
+			t.Errorf("Expected cmd to be dns-list_records, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected HealthCheck not to return error, got %v", err)
+	}
+}
+
+func TestHealthCheckInvalidKey(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":"invalid_api_key"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.HealthCheck(context.Background())
+	if !errors.Is(err, ErrNonSuccessResult) {
+		t.Errorf("Expected HealthCheck to return ErrNonSuccessResult, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "invalid_api_key") {
+		t.Errorf("Expected err to contain invalid_api_key, but was %v instead", err.Error())
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actual := r.URL.Query().Get("cmd"); actual != "api-list_accessible_cmds" {
+			t.Errorf("Expected cmd to be api-list_accessible_cmds, got %v", actual)
+		}
+		w.Header().Set("Date", "Tue, 15 Oct 2024 12:34:56 GMT")
+		_, _ = fmt.Fprint(w, `{"result":"success","data":[]}`)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	serverTime, err := c.ServerTime()
+	if err != nil {
+		t.Errorf("Expected ServerTime not to return error, got %v", err)
+	}
+	expected := time.Date(2024, time.October, 15, 12, 34, 56, 0, time.UTC)
+	if !serverTime.Equal(expected) {
+		t.Errorf("Expected server time to be %v, got %v", expected, serverTime)
+	}
+}
+
+func TestServerTimeContextRetriesTransientErrors(t *testing.T) {
+	attempts := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Date", "Tue, 15 Oct 2024 12:34:56 GMT")
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"result":"success","data":[]}`)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRetry(2, time.Millisecond))
+	if _, err := c.ServerTimeContext(context.Background()); err != nil {
+		t.Errorf("Expected ServerTimeContext not to return error, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %v", attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ServerTimeContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ServerTimeContext with a cancelled context to return context.Canceled, got %v", err)
+	}
+}
+
+func TestServerTimeInvalidDate(t *testing.T) {
+	expectedErrContent := "failed to parse Date header"
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "yesterday")
+	}))
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.ServerTime(); err == nil {
+		t.Error("Expected ServerTime to return error, got nil")
+	} else if !strings.Contains(err.Error(), expectedErrContent) {
+		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
+	}
+}
+
+func TestCreateRecordRejectsUnsupportedRecordType(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		t.Errorf("Expected no request to be made, got type %v", r.URL.Query().Get("type"))
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXTT", Value: "testValue"}, "")
+	if !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("Expected CreateRecord to return ErrUnsupportedRecordType, got %v", err)
+	}
+}
+
+func TestCreateRecordWithoutRecordTypeValidation(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if actual := r.URL.Query().Get("type"); actual != "NEWTYPE" {
+			t.Errorf("Expected type to be NEWTYPE, got %v", actual)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithoutRecordTypeValidation())
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "NEWTYPE", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
 func mockHttpResponse(status int, body string, validator func(*http.Request)) *httptest.Server {
 	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if validator != nil {
@@ -0,0 +1,150 @@
+package dreamhost
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNonSuccessResult is matched by every ApiError, i.e. whenever the API responds with a result other than
+	// "success".
+	ErrNonSuccessResult = errors.New("dreamhost API returned non-successful result")
+	// ErrUniqueIDUsed is matched by an ApiError when the unique_id has already been used by a previous request.
+	ErrUniqueIDUsed = errors.New("dreamhost unique_id already used")
+	// ErrRecordExists is matched by an ApiError when creating a record that already exists.
+	ErrRecordExists = errors.New("dreamhost record already exists")
+	// ErrNoSuchRecord is matched by an ApiError when deleting a record that doesn't exist.
+	ErrNoSuchRecord = errors.New("dreamhost record does not exist")
+	// ErrUnexpectedStatus is matched by a StatusError, i.e. for responses with a non-2xx HTTP status code.
+	ErrUnexpectedStatus = errors.New("dreamhost API returned unexpected status code")
+
+	// ErrMalformedResponse is returned when the API responds with JSON whose result field is missing or empty, which
+	// usually means the response came from something other than the DreamHost API (e.g. a misbehaving proxy).
+	ErrMalformedResponse = errors.New("dreamhost API returned a malformed response")
+	// ErrZoneNotAllowed is returned, without contacting the API, for a record outside the zones the client is allowed
+	// to modify.
+	ErrZoneNotAllowed = errors.New("dreamhost record is not in an allowed zone")
+	// ErrCircuitOpen is returned, without contacting the API, while the client's circuit breaker is open.
+	ErrCircuitOpen = errors.New("dreamhost circuit breaker is open")
+	// ErrClosed is returned for requests made after the client has been closed.
+	ErrClosed = errors.New("dreamhost client is closed")
+	// ErrRecordNotOwned is returned by DeleteOwnedRecord for a record lacking the client's ownership marker.
+	ErrRecordNotOwned = errors.New("dreamhost record is not owned by this client")
+	// ErrInvalidBaseURL is returned when creating a client with a base URL that isn't an http or https URL with a host.
+	ErrInvalidBaseURL = errors.New("invalid dreamhost API base URL")
+	// ErrResponseTooLarge is returned when a response body exceeds the size the client is willing to read.
+	ErrResponseTooLarge = errors.New("dreamhost API response too large")
+	// ErrUnsupportedRecordType is returned, without contacting the API, for a record type DreamHost doesn't support.
+	ErrUnsupportedRecordType = errors.New("unsupported record type")
+	// ErrReadOnly is returned by methods that would modify DNS records when the client is in read-only mode.
+	ErrReadOnly = errors.New("dreamhost client is read-only")
+	// ErrRecordNotEditable is returned, when checking editability is enabled, for deleting a record that DreamHost
+	// manages itself.
+	ErrRecordNotEditable = errors.New("dreamhost record is not editable")
+	// ErrBatchNotSubmitted is reported for the valid records of a batch that wasn't submitted because another of its
+	// records is invalid.
+	ErrBatchNotSubmitted = errors.New("dreamhost batch was not submitted")
+)
+
+// dataErrors maps the data values of error responses to the sentinel errors they match, for DefaultDataClassifier.
+var dataErrors = map[string]error{
+	"unique_id_already_used":             ErrUniqueIDUsed,
+	"record_already_exists_remove_first": ErrRecordExists,
+	"no_such_record":                     ErrNoSuchRecord,
+	"no_such_type":                       ErrNoSuchRecord,
+	"no_such_value":                      ErrNoSuchRecord,
+}
+
+// Classification is how the client treats an error response, as decided by a DataClassifier. The zero value is a
+// permanent error that matches no sentinel error beyond ErrNonSuccessResult.
+type Classification struct {
+	// Err is the sentinel error, such as ErrRecordExists, that the ApiError matches, which decides whether callers
+	// such as CreateRecord treat it as success.
+	Err error
+	// Retryable marks the error as transient, so that the command is retried as configured by WithRetry.
+	Retryable bool
+}
+
+// DataClassifier classifies an error response to cmd from its data, or from its reason if the data's classification
+// is the zero value.
+type DataClassifier func(cmd, data string) Classification
+
+// DefaultDataClassifier is the DataClassifier used unless WithDataClassifier sets another. It matches the responses
+// DreamHost is known to return for duplicate unique_ids and records that already exist or don't exist, and treats no
+// response as retryable.
+func DefaultDataClassifier(cmd, data string) Classification {
+	return Classification{Err: dataErrors[strings.TrimSpace(data)]}
+}
+
+// WithDataClassifier replaces DefaultDataClassifier, e.g. to recognise new or account-specific responses without
+// waiting for a release. To extend the built-in rules, fall back to DefaultDataClassifier.
+func WithDataClassifier(classifier DataClassifier) Option {
+	return func(o *options) error {
+		if classifier == nil {
+			return errors.New("data classifier must not be nil")
+		}
+		o.client.dataClassifier = classifier
+		return nil
+	}
+}
+
+// ApiError is returned when the API responds with a result other than "success". It matches ErrNonSuccessResult
+// and, when Result is "error", a more specific sentinel error such as ErrRecordExists depending on Data, or on Reason
+// if Data isn't recognised, as classified by the client's DataClassifier.
+type ApiError struct {
+	Result string
+	Data   string
+	Reason string
+	// Response is the response as returned by the API, e.g. for inspecting structured Data while debugging.
+	Response *DreamhostResponse
+
+	// class is set by the client that received the response. ApiErrors made elsewhere use DefaultDataClassifier.
+	class      Classification
+	classified bool
+}
+
+// classify classifies an error response to cmd with classifier.
+func classify(classifier DataClassifier, cmd string, e *ApiError) Classification {
+	if e.Result != "error" {
+		return Classification{}
+	}
+	class := classifier(cmd, e.Data)
+	if class == (Classification{}) {
+		class = classifier(cmd, e.Reason)
+	}
+	return class
+}
+
+func (e *ApiError) Error() string {
+	msg := fmt.Sprintf("%v: %v: %v", ErrNonSuccessResult, e.Result, e.Data)
+	// The reason, when present, is usually the most actionable part of the error
+	if e.Reason != "" {
+		msg += fmt.Sprintf(" (reason: %v)", e.Reason)
+	}
+	return msg
+}
+
+func (e *ApiError) Is(target error) bool {
+	if target == ErrNonSuccessResult {
+		return true
+	}
+	class := e.class
+	if !e.classified {
+		class = classify(DefaultDataClassifier, "", e)
+	}
+	return class.Err != nil && target == class.Err
+}
+
+// StatusError is returned when the API responds with a non-2xx HTTP status code. It matches ErrUnexpectedStatus.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v %v", ErrUnexpectedStatus, e.Code)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrUnexpectedStatus
+}
@@ -0,0 +1,196 @@
+package dreamhost
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestApiErrorIs(t *testing.T) {
+	cases := map[string]error{
+		"record_already_exists_remove_first": ErrRecordExists,
+		"unique_id_already_used":             ErrUniqueIDUsed,
+	}
+
+	for data, expected := range cases {
+		err := error(&ApiError{Result: "error", Data: data})
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected ApiError with data %v to match %v", data, expected)
+		}
+		if !errors.Is(err, ErrNonSuccessResult) {
+			t.Errorf("Expected ApiError with data %v to match ErrNonSuccessResult", data)
+		}
+	}
+
+	err := error(&ApiError{Result: "error", Data: "no_such_zone"})
+	if errors.Is(err, ErrRecordExists) || errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected ApiError with unknown data not to match a specific sentinel")
+	}
+}
+
+func TestApiErrorIsRequiresErrorResult(t *testing.T) {
+	err := error(&ApiError{Result: "warning", Data: "unique_id_already_used"})
+	if errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected ApiError with result warning not to match ErrUniqueIDUsed")
+	}
+	if !errors.Is(err, ErrNonSuccessResult) {
+		t.Errorf("Expected ApiError with result warning to match ErrNonSuccessResult")
+	}
+}
+
+func TestApiErrorIsMatchesReasonAndPaddedData(t *testing.T) {
+	if err := error(&ApiError{Result: "error", Reason: "unique_id_already_used"}); !errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected ApiError with reason unique_id_already_used to match ErrUniqueIDUsed")
+	}
+	if err := error(&ApiError{Result: "error", Data: "unique_id_already_used\n"}); !errors.Is(err, ErrUniqueIDUsed) {
+		t.Errorf("Expected ApiError with padded data to match ErrUniqueIDUsed")
+	}
+}
+
+func TestApiErrorIncludesResponse(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":{"code":"invalid_record","field":"value"},"reason":"bad value"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected CreateRecord to return an ApiError, got %v", err)
+	}
+	if apiErr.Reason != "bad value" {
+		t.Errorf("Expected reason to be bad value, got %v", apiErr.Reason)
+	}
+	if apiErr.Response == nil || string(apiErr.Response.Data) != `{"code":"invalid_record","field":"value"}` {
+		t.Errorf("Expected the response to include the raw data, got %+v", apiErr.Response)
+	}
+}
+
+func TestCreateRecordSuccessWithUniqueIdUsedData(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"unique_id_already_used"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithStrictUniqueID())
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "123")
+	if err != nil {
+		t.Errorf("Expected CreateRecord not to return error for a success response, got %v", err)
+	}
+}
+
+func TestCreateRecordReturnsApiError(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":"record_already_exists_remove_first"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, ErrRecordExists) {
+		t.Errorf("Expected CreateRecord to return ErrRecordExists, got %v", err)
+	}
+
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected CreateRecord to return ApiError, got %v", err)
+	}
+	if apiErr.Data != "record_already_exists_remove_first" {
+		t.Errorf("Expected Data to be record_already_exists_remove_first, got %v", apiErr.Data)
+	}
+}
+
+func TestCreateRecordSurfacesReason(t *testing.T) {
+	reason := "The API key you provided does not have access to this command"
+
+	svr := mockHttpResponse(200, `{"result":"error","data":"no_such_cmd","reason":"`+reason+`"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
+	}
+	for _, expected := range []string{"no_such_cmd", reason} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected err to contain %v, but was %v instead", expected, err.Error())
+		}
+	}
+
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected CreateRecord to return ApiError, got %v", err)
+	}
+	if apiErr.Data != "no_such_cmd" || apiErr.Reason != reason {
+		t.Errorf("Expected Data and Reason to be no_such_cmd and %v, got %v and %v", reason, apiErr.Data, apiErr.Reason)
+	}
+}
+
+func TestApiErrorOmitsEmptyReason(t *testing.T) {
+	err := &ApiError{Result: "error", Data: "record_already_exists_remove_first"}
+	if strings.Contains(err.Error(), "reason") {
+		t.Errorf("Expected err not to mention a reason, got %v", err.Error())
+	}
+}
+
+func TestCreateRecordReturnsErrUnexpectedStatus(t *testing.T) {
+	svr := mockHttpResponse(403, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("Expected CreateRecord to return ErrUnexpectedStatus, got %v", err)
+	}
+}
+
+func TestWithDataClassifier(t *testing.T) {
+	var calls int32
+	svr := mockHttpResponse(200, `{"result":"error","data":"internal_error"}`, func(r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	})
+	defer svr.Close()
+
+	classifier := func(cmd, data string) Classification {
+		if cmd == "dns-add_record" && data == "internal_error" {
+			return Classification{Retryable: true}
+		}
+		return DefaultDataClassifier(cmd, data)
+	}
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(3, time.Millisecond))
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrNonSuccessResult) || IsRetryable(err) {
+		t.Errorf("Expected CreateRecord to return a permanent ApiError by default, got %v", err)
+	}
+	if n := atomic.SwapInt32(&calls, 0); n != 1 {
+		t.Errorf("Expected 1 request by default, got %v", n)
+	}
+
+	c, _ = NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(3, time.Millisecond), WithDataClassifier(classifier))
+	if err := c.CreateRecord(r, ""); !errors.Is(err, ErrNonSuccessResult) || !IsRetryable(err) {
+		t.Errorf("Expected CreateRecord to return a retryable ApiError, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected 3 requests with the classifier, got %v", n)
+	}
+}
+
+func TestWithDataClassifierSentinel(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return listRecordsBody
+		}
+		return `{"result":"error","data":"record_exists_already"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithDataClassifier(func(cmd, data string) Classification {
+		if data == "record_exists_already" {
+			return Classification{Err: ErrRecordExists}
+		}
+		return DefaultDataClassifier(cmd, data)
+	}))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	if err := c.CreateRecord(r, ""); err != nil {
+		t.Errorf("Expected CreateRecord to treat the classified response as an existing record, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// discardLogger is the default logger, which logs nothing.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// WithLogger sets the logger the client reports each API command to: successes at info (debug for read-only
+// commands), and failures at warn. The API key is never logged. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			logger = discardLogger
+		}
+		o.client.logger = logger
+		return nil
+	}
+}
+
+// logRequest logs the outcome of a command that started at start. The record r is optional.
+func (c *DNSClient) logRequest(ctx context.Context, level slog.Level, cmd string, r *DNSRecordValue, start time.Time, err error) {
+	attrs := []slog.Attr{
+		slog.String("cmd", cmd),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if r != nil {
+		attrs = append(attrs, slog.String("record", r.Name), slog.String("type", r.RecordType))
+	}
+	if requestId, ok := RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", requestId))
+	}
+
+	var apiErr *ApiError
+	switch {
+	case err == nil:
+		attrs = append(attrs, slog.String("result", "success"))
+	case errors.As(err, &apiErr):
+		attrs = append(attrs, slog.String("result", apiErr.Result), slog.String("data", apiErr.Data))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", c.redact(err.Error())))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "dreamhost API command failed", attrs...)
+		return
+	}
+	c.logger.LogAttrs(ctx, level, "dreamhost API command succeeded", attrs...)
+}
+
+// refuseReadOnly logs that a read-only client refused to perform action on r, and returns the error matching
+// ErrReadOnly to report it with.
+func (c *DNSClient) refuseReadOnly(ctx context.Context, action string, r DNSRecordValue) error {
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "dreamhost client is read-only, refusing to "+action+" record",
+		slog.String("record", r.Name),
+		slog.String("type", r.RecordType),
+	)
+	return fmt.Errorf("%w: refusing to %v %v record %v", ErrReadOnly, action, r.RecordType, r.Name)
+}
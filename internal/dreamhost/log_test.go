@@ -0,0 +1,119 @@
+package dreamhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCreateRecordLogsAtInfo(t *testing.T) {
+	apiKey := "apikey123"
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, _ := NewClientWithOptions(apiKey, WithBaseURL(svr.URL), WithLogger(logger))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Fatalf("Expected CreateRecord not to return error, got %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON log entry, got %v", buf.String())
+	}
+	expected := map[string]string{
+		"level":  "INFO",
+		"cmd":    "dns-add_record",
+		"record": "example.com",
+		"type":   "TXT",
+		"result": "success",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Expected log attribute %v to be %v, got %v", k, v, entry[k])
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Error("Expected log entry to include the duration")
+	}
+}
+
+func TestCreateRecordLogsErrorsAtWarn(t *testing.T) {
+	apiKey := "apikey123"
+	svr := mockHttpResponse(200, "invalid", nil)
+	svr.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	c, _ := NewClientWithOptions(apiKey, WithBaseURL(svr.URL), WithLogger(logger), WithRetry(1, 0))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
+	}
+
+	if !strings.Contains(buf.String(), `"level":"WARN"`) {
+		t.Errorf("Expected a warning to be logged, got %v", buf.String())
+	}
+	if strings.Contains(buf.String(), apiKey) {
+		t.Errorf("Expected log not to contain the API key, got %v", buf.String())
+	}
+}
+
+func TestReadOnlyRefusalsLogAtWarn(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	c, _ := NewClientWithOptions("apikey123", WithReadOnly(true), WithLogger(logger))
+
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	_ = c.CreateRecord(r, "")
+	_ = c.CreateRecordWithOptions(r, CreateOptions{})
+	_ = c.DeleteRecord(r, "")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log entries, got %v", buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a JSON log entry, got %v", line)
+		}
+		if entry["level"] != "WARN" || entry["record"] != "example.com" || entry["type"] != "TXT" {
+			t.Errorf("Expected a warning naming the record, got %v", line)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	apiKey := "apikey123"
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		t.Errorf("Expected no request to be made, got cmd %v", r.URL.Query().Get("cmd"))
+	})
+	defer svr.Close()
+
+	for _, usePost := range []bool{false, true} {
+		var buf bytes.Buffer
+		c, _ := NewClientWithOptions(apiKey, WithBaseURL(svr.URL), WithDryRun(), WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+		c.usePOST = usePost
+
+		r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+		if err := c.CreateRecord(r, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+		if err := c.DeleteRecord(r, ""); err != nil {
+			t.Errorf("Expected DeleteRecord not to return error, got %v", err)
+		}
+
+		for _, expected := range []string{"cmd=dns-add_record", "cmd=dns-remove_record", "record=example.com", "key=REDACTED"} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("Expected log to contain %v, got %v", expected, buf.String())
+			}
+		}
+		if strings.Contains(buf.String(), apiKey) {
+			t.Errorf("Expected log not to contain the API key, got %v", buf.String())
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// operationContext bounds an operation started with ctx to the client's operation timeout, if any. A deadline ctx
+// already has is kept if it's sooner, so operations made of others are bounded as a whole.
+func (c *DNSClient) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.operationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.operationTimeout)
+}
+
+// WithOperationTimeout bounds every operation of the client, such as creating a record or waiting for one to
+// propagate, to d in total, including its retries and polling. This is separate from the http.Client's timeout, which
+// bounds each request. The operation's requests are bound to its deadline, so a retry isn't attempted past it. By
+// default, operations are only bounded by the contexts they're given.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("operation timeout must be positive, got %v", d)
+		}
+		o.client.operationTimeout = d
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithOperationTimeoutCapsRetriesAndPolling(t *testing.T) {
+	svr := mockHttpResponse(503, "", nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRetry(1000, time.Millisecond),
+		WithPollInterval(10*time.Millisecond), WithOperationTimeout(200*time.Millisecond))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+
+	for op, run := range map[string]func() error{
+		"CreateRecord":    func() error { return c.CreateRecord(r, "") },
+		"WaitForDeletion": func() error { return c.WaitForDeletion(context.Background(), r) },
+	} {
+		start := time.Now()
+		err := run()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%v: Expected context.DeadlineExceeded, got %v", op, err)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("%v: Expected to give up after the 200ms operation timeout, took %v", op, elapsed)
+		}
+	}
+}
+
+func TestWithOperationTimeoutKeepsSoonerDeadline(t *testing.T) {
+	svr := mockHttpResponse(503, "", nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithRetry(1000, time.Millisecond),
+		WithOperationTimeout(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.ListRecordsContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ListRecordsContext to return context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the caller's sooner deadline to apply, took %v", elapsed)
+	}
+}
+
+func TestWithOperationTimeoutInvalid(t *testing.T) {
+	if _, err := NewClientWithOptions("apikey123", WithOperationTimeout(0)); err == nil {
+		t.Error("Expected NewClientWithOptions to reject a zero operation timeout, got nil")
+	}
+}
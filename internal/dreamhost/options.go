@@ -0,0 +1,445 @@
+package dreamhost
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const defaultTimeout = 15 * time.Second
+
+// Option configures a DNSClient created by NewClientWithOptions.
+type Option func(*options) error
+
+// options holds the settings that only matter while a DNSClient is being constructed, along with the client itself
+// for options that configure it directly.
+type options struct {
+	client       *DNSClient
+	httpClient   *http.Client
+	baseUrl      string
+	baseUrlPath  string
+	timeout      time.Duration
+	tlsConfig    *tls.Config
+	proxyUrl     *url.URL
+	roundTripper http.RoundTripper
+}
+
+// NewClientWithOptions creates a DNSClient using the given API key, configured by opts. Surrounding whitespace is
+// trimmed from the key.
+func NewClientWithOptions(apiKey string, opts ...Option) (*DNSClient, error) {
+	// Keys pasted from the panel or mounted from files often carry a trailing newline, which the API would reject as an
+	// invalid key
+	untrimmedKey := apiKey
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return nil, errors.New("empty apiKey")
+	}
+
+	o := &options{
+		client: &DNSClient{
+			apiKey:                   apiKey,
+			userAgent:                agentString,
+			retryAttempts:            defaultRetryAttempts,
+			retryBaseDelay:           defaultRetryBaseDelay,
+			maxRetryAfter:            defaultMaxRetryAfter,
+			resolver:                 NewDNSResolver(defaultResolverAddress),
+			pollInterval:             defaultPollInterval,
+			propagationWarnThreshold: defaultPropagationWarnThreshold,
+			logger:                   discardLogger,
+			clock:                    realClock{},
+			format:                   jsonFormat,
+			responseSizeLimit:        defaultResponseSizeLimit,
+			addCmd:                   addRecordCmd,
+			removeCmd:                removeRecordCmd,
+		},
+		baseUrl: dreamhostBaseUrl,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	apiUrl, err := url.Parse(o.baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+	if apiUrl.Scheme != "http" && apiUrl.Scheme != "https" {
+		return nil, fmt.Errorf("%w: %q must use the http or https scheme", ErrInvalidBaseURL, o.baseUrl)
+	}
+	if apiUrl.Host == "" {
+		return nil, fmt.Errorf("%w: %q has no host", ErrInvalidBaseURL, o.baseUrl)
+	}
+	if o.baseUrlPath != "" {
+		apiUrl = apiUrl.ResolveReference(&url.URL{Path: "/" + strings.TrimPrefix(o.baseUrlPath, "/")})
+	}
+	o.client.BaseURL = apiUrl
+
+	if apiKey != untrimmedKey {
+		o.client.logger.Warn("trimmed whitespace from the dreamhost API key")
+	}
+
+	o.client.client = o.httpClient
+	if o.client.client == nil {
+		o.client.client = &http.Client{
+			// There is no timeout by default.
+			Timeout:   o.timeout,
+			Transport: o.transport(),
+		}
+	}
+
+	return o.client, nil
+}
+
+// NewClientFromKeyFile creates a DNSClient using the API key stored in the file at path, e.g. a mounted secret,
+// configured by opts. Surrounding whitespace, such as a trailing newline, is ignored.
+func NewClientFromKeyFile(path string, opts ...Option) (*DNSClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+	apiKey := strings.TrimSpace(string(data))
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key file %v is empty", path)
+	}
+	return NewClientWithOptions(apiKey, opts...)
+}
+
+// transport returns the transport for the default http.Client, or nil to use http.DefaultTransport if no option
+// customizes it.
+func (o *options) transport() http.RoundTripper {
+	if o.tlsConfig == nil && o.proxyUrl == nil {
+		return o.roundTripper
+	}
+
+	base := http.DefaultTransport.(*http.Transport)
+	if o.roundTripper != nil {
+		custom, ok := o.roundTripper.(*http.Transport)
+		if !ok {
+			// Only an *http.Transport has TLS and proxy settings to apply
+			return o.roundTripper
+		}
+		base = custom
+	}
+
+	transport := base.Clone()
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig
+	}
+	if o.proxyUrl != nil {
+		transport.Proxy = http.ProxyURL(o.proxyUrl)
+	}
+	return transport
+}
+
+// WithHTTPClient sets the http.Client used to send requests. A nil client selects the default. A supplied client is
+// used as is, taking precedence over options that configure the default client, such as WithTimeout and
+// WithHTTPTransport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) error {
+		o.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithBaseURL sets the URL of the DreamHost API. An empty URL selects the default, https://api.dreamhost.com/.
+func WithBaseURL(baseUrl string) Option {
+	return func(o *options) error {
+		if baseUrl != "" {
+			o.baseUrl = baseUrl
+		}
+		return nil
+	}
+}
+
+// WithBaseURLPath sets the path of the base URL, e.g. to send requests through an API gateway that serves the
+// DreamHost API under a path prefix such as /dreamhost/.
+func WithBaseURLPath(path string) Option {
+	return func(o *options) error {
+		o.baseUrlPath = path
+		return nil
+	}
+}
+
+// WithHTTPTransport sets the transport of the default http.Client, e.g. to tune connection pooling, keep-alives or dial
+// timeouts while keeping the default client's timeout. If rt is an *http.Transport, WithTLSConfig and WithProxy are
+// applied to a copy of it; other RoundTrippers are used as is. It has no effect when WithHTTPClient supplies a client.
+func WithHTTPTransport(rt http.RoundTripper) Option {
+	return func(o *options) error {
+		o.roundTripper = rt
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of the default http.Client, which is 15 seconds unless overridden. It has no effect when
+// WithHTTPClient supplies a client: the supplied client's own Timeout wins. A deadline on the context passed to a
+// request takes precedence over either.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) error {
+		o.client.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithAccountID sends the account_id parameter with every command, to select which account a key with access to
+// several accounts acts on.
+func WithAccountID(id string) Option {
+	return func(o *options) error {
+		o.client.accountId = id
+		return nil
+	}
+}
+
+// WithReadOnly, when readOnly is true, makes CreateRecord and DeleteRecord return ErrReadOnly without contacting the
+// API. Read-only calls such as ListRecords and ServerTime still work, so an instance can safely be pointed at real
+// credentials.
+func WithReadOnly(readOnly bool) Option {
+	return func(o *options) error {
+		o.client.readOnly = readOnly
+		return nil
+	}
+}
+
+// WithPOST sends the command parameters as a form-encoded POST body rather than in the query string of a GET, which
+// keeps the API key out of the request line and therefore out of proxy and access logs.
+func WithPOST() Option {
+	return func(o *options) error {
+		o.client.usePOST = true
+		return nil
+	}
+}
+
+// WithIdempotencyKeyHeader also sends the unique_id of a request in the named HTTP header (e.g. "Idempotency-Key"),
+// for proxies that deduplicate requests themselves. The unique_id query parameter is always sent.
+func WithIdempotencyKeyHeader(header string) Option {
+	return func(o *options) error {
+		o.client.idempotencyKeyHeader = header
+		return nil
+	}
+}
+
+// WithResponseHeaderHook calls hook with a copy of the response headers after every request that gets an HTTP
+// response, including error responses. It can be used to track rate-limit headers such as X-RateLimit-Remaining.
+func WithResponseHeaderHook(hook func(http.Header)) Option {
+	return func(o *options) error {
+		o.client.responseHeaderHook = hook
+		return nil
+	}
+}
+
+// WithRetry sets the maximum number of attempts for requests that fail transiently, with a 429 or 5xx status code or a
+// network error, and the delay before the first retry. Values of attempts below 1 are treated as 1, i.e. no retries.
+func WithRetry(attempts int, baseDelay time.Duration) Option {
+	return func(o *options) error {
+		o.client.retryAttempts = attempts
+		o.client.retryBaseDelay = baseDelay
+		return nil
+	}
+}
+
+// WithBackoff sets the strategy deciding how long to wait between retries. By default, delays grow exponentially from
+// the base delay set by WithRetry.
+func WithBackoff(backoff Backoff) Option {
+	return func(o *options) error {
+		o.client.backoff = backoff
+		return nil
+	}
+}
+
+// WithMaxRetryAfter caps how long the client waits before retrying when a throttled or failed response carries a
+// Retry-After header, which is 30 seconds unless overridden. Longer requested delays are shortened to d. A d of 0
+// ignores Retry-After, always using the exponential backoff configured by WithRetry.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(o *options) error {
+		o.client.maxRetryAfter = d
+		return nil
+	}
+}
+
+// WithRetryMalformedResponses makes the client retry, as configured by WithRetry, responses that can't be parsed or
+// that lack a result, as are returned when something other than the API, such as a proxy, answers transiently. By
+// default, such responses fail immediately with an error matching ErrMalformedResponse or a parse error.
+func WithRetryMalformedResponses() Option {
+	return func(o *options) error {
+		o.client.retryMalformed = true
+		return nil
+	}
+}
+
+// WithRateLimit limits the client to requestsPerSecond requests per second, with bursts of up to burst requests.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *options) error {
+		if requestsPerSecond <= 0 || burst < 1 {
+			return errors.New("rate limit must allow at least one request")
+		}
+		o.client.rateLimiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+		return nil
+	}
+}
+
+// WithMaxConcurrency limits the client to n requests in flight at once, shared by all goroutines using it. Further
+// requests wait for a slot, or until their context is done.
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) error {
+		if n < 1 {
+			return fmt.Errorf("max concurrency must be at least 1, got %v", n)
+		}
+		o.client.inFlight = make(chan struct{}, n)
+		return nil
+	}
+}
+
+// WithResponseSizeLimit sets the largest response body the client reads, which is 10 MiB unless overridden. Larger
+// responses fail with an error matching ErrResponseTooLarge, rather than exhausting memory.
+func WithResponseSizeLimit(n int64) Option {
+	return func(o *options) error {
+		if n < 1 {
+			return fmt.Errorf("response size limit must be positive, got %v", n)
+		}
+		o.client.responseSizeLimit = n
+		return nil
+	}
+}
+
+// WithDefaultTTL sets the TTL in seconds of records created without one, e.g. a short TTL so that the removal of
+// challenge records propagates quickly. By default no TTL is sent, leaving DreamHost to use its own default.
+func WithDefaultTTL(seconds int) Option {
+	return func(o *options) error {
+		if seconds < 0 {
+			return fmt.Errorf("default TTL must not be negative, got %v", seconds)
+		}
+		o.client.defaultTTL = seconds
+		return nil
+	}
+}
+
+// WithAutoUniqueID makes CreateRecord derive a unique_id with DeriveUniqueID when the caller doesn't supply one, so
+// that retried creates of the same record are deduplicated. Note that this also makes the API ignore a create of a
+// record that was previously created and then deleted, since the derived unique_id has already been used; see
+// WithUniqueIDGenerator for alternatives.
+func WithAutoUniqueID() Option {
+	return WithUniqueIDGenerator(ContentHashUniqueIDs{})
+}
+
+// WithStrictUniqueID makes CreateRecord and DeleteRecord return an error matching ErrUniqueIDUsed when the API reports
+// that their unique_id was already used, rather than assuming the earlier request with that id did the same thing and
+// reporting success.
+func WithStrictUniqueID() Option {
+	return func(o *options) error {
+		o.client.strictUniqueId = true
+		return nil
+	}
+}
+
+// WithEditableCheck makes DeleteRecord look the record up before deleting it, and return an error matching
+// ErrRecordNotEditable if DreamHost manages it, rather than the API's less descriptive error. This costs an extra
+// request per delete.
+func WithEditableCheck() Option {
+	return func(o *options) error {
+		o.client.checkEditable = true
+		return nil
+	}
+}
+
+// WithAddCommand sets the API command used to create records, which is dns-add_record unless overridden, e.g. for a
+// compatible proxy or a renamed command.
+func WithAddCommand(cmd string) Option {
+	return func(o *options) error {
+		if cmd == "" {
+			return errors.New("add command must not be empty")
+		}
+		o.client.addCmd = cmd
+		return nil
+	}
+}
+
+// WithRemoveCommand sets the API command used to delete records, which is dns-remove_record unless overridden.
+func WithRemoveCommand(cmd string) Option {
+	return func(o *options) error {
+		if cmd == "" {
+			return errors.New("remove command must not be empty")
+		}
+		o.client.removeCmd = cmd
+		return nil
+	}
+}
+
+// WithEmptyValues leaves it to the API to decide whether an empty value is valid for record types other than A, AAAA,
+// CNAME and TXT, which always require one. By default, records with an empty value are rejected without contacting the
+// API.
+func WithEmptyValues() Option {
+	return func(o *options) error {
+		o.client.allowEmptyValues = true
+		return nil
+	}
+}
+
+// WithLiteralTXTQuotes sends TXT values as given. By default, a TXT value wrapped in double quotes, as it would be
+// written in a zone file, has one layer of quotes stripped, since DreamHost would otherwise store the quotes as part of
+// the value.
+func WithLiteralTXTQuotes() Option {
+	return func(o *options) error {
+		o.client.keepTXTQuotes = true
+		return nil
+	}
+}
+
+// WithoutRecordTypeValidation leaves validating record types to the API, rather than rejecting types other than the
+// ones DreamHost is known to support before sending a request. This allows using types added to the API later.
+func WithoutRecordTypeValidation() Option {
+	return func(o *options) error {
+		o.client.skipTypeValidation = true
+		return nil
+	}
+}
+
+// WithDryRun makes CreateRecord and DeleteRecord log the request they would send (see WithLogger) and report success,
+// without contacting the API. Read-only commands are still sent.
+func WithDryRun() Option {
+	return func(o *options) error {
+		o.client.dryRun = true
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default http.Client's transport, e.g. to trust the CA of a
+// TLS-intercepting proxy through RootCAs, or to pin DreamHost's certificate through VerifyPeerCertificate. It has no
+// effect when WithHTTPClient supplies a client.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) error {
+		o.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithProxy sends requests from the default http.Client through the HTTP proxy at proxyUrl, instead of any proxy
+// configured by the environment. It has no effect when WithHTTPClient supplies a client.
+func WithProxy(proxyUrl string) Option {
+	return func(o *options) error {
+		u, err := url.Parse(proxyUrl)
+		if err != nil {
+			return fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy URL %q has no host", proxyUrl)
+		}
+		o.proxyUrl = u
+		return nil
+	}
+}
@@ -0,0 +1,513 @@
+package dreamhost
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestNewClientWithOptionsDefaults(t *testing.T) {
+	c, err := NewClientWithOptions("test123")
+	if err != nil {
+		t.Fatalf("expected NewClientWithOptions err to be nil, got %v", err)
+	}
+	if actual := c.BaseURL.String(); actual != dreamhostBaseUrl {
+		t.Errorf("expected BaseURL to be %v, got %v", dreamhostBaseUrl, actual)
+	}
+	if c.client.Timeout != defaultTimeout {
+		t.Errorf("expected timeout to be %v, got %v", defaultTimeout, c.client.Timeout)
+	}
+	if c.userAgent != agentString {
+		t.Errorf("expected user agent to be %v, got %v", agentString, c.userAgent)
+	}
+	if c.rateLimiter != nil {
+		t.Error("expected no rate limiter by default")
+	}
+}
+
+func TestNewClientWithOptionsWithEmptyApiKey(t *testing.T) {
+	c, err := NewClientWithOptions("", WithBaseURL("https://example.com/"))
+	if err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+	if c != nil {
+		t.Error("expected NewClientWithOptions DNSClient to be nil, was not nil")
+	}
+}
+
+func TestNewClientWithOptionsTrimsApiKey(t *testing.T) {
+	for _, apiKey := range []string{"test123\n", "  test123 \r\n"} {
+		var buf bytes.Buffer
+		c, err := NewClientWithOptions(apiKey, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+		if err != nil {
+			t.Fatalf("expected NewClientWithOptions err to be nil, got %v", err)
+		}
+		if c.apiKey != "test123" {
+			t.Errorf("expected apiKey %q to be trimmed to test123, got %q", apiKey, c.apiKey)
+		}
+		if !strings.Contains(buf.String(), "trimmed whitespace") || strings.Contains(buf.String(), "test123") {
+			t.Errorf("expected a warning about trimming that doesn't include the key, got %v", buf.String())
+		}
+	}
+}
+
+func TestNewClientWithOptionsWithWhitespaceApiKey(t *testing.T) {
+	if _, err := NewClientWithOptions(" \n"); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
+
+func TestNewClientWithOptionsDoesNotWarnForCleanApiKey(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewClientWithOptions("test123", WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)))); err != nil {
+		t.Fatalf("expected NewClientWithOptions err to be nil, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be logged, got %v", buf.String())
+	}
+}
+
+func TestNewClientFromKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("test123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClientFromKeyFile(path, WithUserAgent("custom-agent/1.0"))
+	if err != nil {
+		t.Fatalf("expected NewClientFromKeyFile err to be nil, got %v", err)
+	}
+	if c.apiKey != "test123" {
+		t.Errorf("expected apiKey to be test123, got %q", c.apiKey)
+	}
+	if c.userAgent != "custom-agent/1.0" {
+		t.Errorf("expected options to be applied, got user agent %v", c.userAgent)
+	}
+}
+
+func TestNewClientFromKeyFileWithEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte(" \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClientFromKeyFile(path)
+	if err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected NewClientFromKeyFile to report an empty file, got %v", err)
+	}
+	if c != nil {
+		t.Error("expected NewClientFromKeyFile DNSClient to be nil, was not nil")
+	}
+}
+
+func TestNewClientFromKeyFileWithMissingFile(t *testing.T) {
+	c, err := NewClientFromKeyFile(filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected NewClientFromKeyFile to return os.ErrNotExist, got %v", err)
+	}
+	if c != nil {
+		t.Error("expected NewClientFromKeyFile DNSClient to be nil, was not nil")
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidBaseUrl(t *testing.T) {
+	c, err := NewClientWithOptions("test123", WithBaseURL("\x7f"))
+	if err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+	if c != nil {
+		t.Error("expected NewClientWithOptions DNSClient to be nil, was not nil")
+	}
+}
+
+func TestNewClientWithOptionsValidatesBaseUrl(t *testing.T) {
+	tests := map[string]bool{
+		"api.dreamhost.com":         false,
+		"ftp://api.dreamhost.com/":  false,
+		"https://":                  false,
+		"https://api.dreamhost.com": true,
+		"http://localhost:8080/gw/": true,
+	}
+	for baseUrl, valid := range tests {
+		c, err := NewClientWithOptions("test123", WithBaseURL(baseUrl))
+		if valid && err != nil {
+			t.Errorf("expected base URL %v to be accepted, got %v", baseUrl, err)
+		}
+		if !valid {
+			if !errors.Is(err, ErrInvalidBaseURL) {
+				t.Errorf("expected base URL %v to return ErrInvalidBaseURL, got %v", baseUrl, err)
+			}
+			if c != nil {
+				t.Errorf("expected DNSClient for base URL %v to be nil, was not nil", baseUrl)
+			}
+		}
+	}
+}
+
+func TestNewClientWithOptionsAppliesOptions(t *testing.T) {
+	c, err := NewClientWithOptions("test123",
+		WithBaseURL("https://gw.example.com/dreamhost/"),
+		WithTimeout(time.Second),
+		WithUserAgent("custom-agent/1.0"),
+		WithRetry(5, time.Second),
+		WithRateLimit(2, 3),
+	)
+	if err != nil {
+		t.Fatalf("expected NewClientWithOptions err to be nil, got %v", err)
+	}
+	if actual := c.BaseURL.String(); actual != "https://gw.example.com/dreamhost/" {
+		t.Errorf("expected BaseURL to be https://gw.example.com/dreamhost/, got %v", actual)
+	}
+	if c.client.Timeout != time.Second {
+		t.Errorf("expected timeout to be 1s, got %v", c.client.Timeout)
+	}
+	if c.userAgent != "custom-agent/1.0" {
+		t.Errorf("expected user agent to be custom-agent/1.0, got %v", c.userAgent)
+	}
+	if c.retryAttempts != 5 || c.retryBaseDelay != time.Second {
+		t.Errorf("expected retry to be 5 attempts from 1s, got %v attempts from %v", c.retryAttempts, c.retryBaseDelay)
+	}
+	if c.rateLimiter == nil || c.rateLimiter.Limit() != rate.Limit(2) || c.rateLimiter.Burst() != 3 {
+		t.Errorf("expected rate limit of 2/s with burst 3, got %+v", c.rateLimiter)
+	}
+}
+
+func TestWithBaseURLPath(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if r.URL.Path != "/gw/dreamhost/" {
+			t.Errorf("Expected path to be /gw/dreamhost/, got %v", r.URL.Path)
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL+"/ignored/"), WithBaseURLPath("gw/dreamhost"))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordSendsCustomUserAgent(t *testing.T) {
+	userAgent := "custom-agent/1.0"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		if r.UserAgent() != userAgent {
+			t.Errorf("Expected user agent to be %v, got %v", userAgent, r.UserAgent())
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithUserAgent(userAgent))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestWithAccountID(t *testing.T) {
+	tests := map[string]string{"configured": "987654", "not configured": ""}
+	for name, accountId := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			q := r.URL.Query()
+			if accountId == "" && q.Has("account_id") {
+				t.Errorf("%v: Expected account_id to not be present, got %v", name, q.Get("account_id"))
+			}
+			if accountId != "" && q.Get("account_id") != accountId {
+				t.Errorf("%v: Expected account_id to be %v, got %v", name, accountId, q.Get("account_id"))
+			}
+		})
+
+		c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithAccountID(accountId))
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("%v: Expected CreateRecord not to return error, got %v", name, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestWithDefaultTTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaultTTL int
+		recordTTL  int
+		expected   string
+	}{
+		{"per-record TTL", 600, 60, "60"},
+		{"default TTL", 300, 0, "300"},
+		{"no TTL", 0, 0, ""},
+	}
+	for _, test := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			q := r.URL.Query()
+			if test.expected == "" && q.Has("ttl") {
+				t.Errorf("%v: Expected ttl to not be present, got %v", test.name, q.Get("ttl"))
+			}
+			if test.expected != "" && q.Get("ttl") != test.expected {
+				t.Errorf("%v: Expected ttl to be %v, got %v", test.name, test.expected, q.Get("ttl"))
+			}
+		})
+
+		c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithDefaultTTL(test.defaultTTL))
+		r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue", TTL: test.recordTTL}
+		if err := c.CreateRecord(r, ""); err != nil {
+			t.Errorf("%v: Expected CreateRecord not to return error, got %v", test.name, err)
+		}
+		svr.Close()
+	}
+}
+
+func TestWithAddAndRemoveCommands(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"ok"}` })
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithAddCommand("dns-add_record_v2"),
+		WithRemoveCommand("dns-remove_record_v2"))
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+	if err := c.CreateRecord(r, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if err := c.DeleteRecord(r, ""); err != nil {
+		t.Errorf("Expected DeleteRecord not to return error, got %v", err)
+	}
+
+	expected := []string{"dns-add_record_v2 testValue", "dns-remove_record_v2 testValue"}
+	if cmds := svr.commands(); !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("Expected commands to be %v, got %v", expected, cmds)
+	}
+}
+
+func TestWithTimeoutAbortsSlowRequests(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithTimeout(20*time.Millisecond), WithRetry(1, 0))
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected CreateRecord to return a timeout error, got %v", err)
+	}
+}
+
+func TestNewClientWithOptionsHTTPClientTimeoutWins(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+	c, err := NewClientWithOptions("test123", WithHTTPClient(httpClient), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("expected NewClientWithOptions err to be nil, got %v", err)
+	}
+	if c.client != httpClient {
+		t.Error("expected the supplied http.Client to be used")
+	}
+	if c.client.Timeout != time.Minute {
+		t.Errorf("expected timeout to be 1m, got %v", c.client.Timeout)
+	}
+}
+
+func TestWithTLSConfigTrustsCustomCA(t *testing.T) {
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}
+
+	// The test server's certificate isn't trusted by default
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithRetry(1, 0))
+	if err := c.CreateRecord(r, ""); err == nil {
+		t.Error("Expected CreateRecord to return error without the custom CA, got nil")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(svr.Certificate())
+	c, _ = NewClientWithOptions("test123", WithBaseURL(svr.URL), WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err := c.CreateRecord(r, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+// recordingTransport records the requests it's asked to send, and passes them on to http.DefaultTransport.
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests = append(rt.requests, req)
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPTransport(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	rt := &recordingTransport{}
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithHTTPTransport(rt), WithTimeout(time.Second))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if len(rt.requests) != 1 || rt.requests[0].URL.Query().Get("cmd") != "dns-add_record" {
+		t.Errorf("Expected the transport to send the dns-add_record request, got %v", rt.requests)
+	}
+	if c.client.Timeout != time.Second {
+		t.Errorf("Expected the default client's timeout to be kept, got %v", c.client.Timeout)
+	}
+}
+
+func TestWithHTTPTransportAppliesTLSConfig(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "api.example.com"}
+	custom := &http.Transport{MaxIdleConnsPerHost: 7}
+	c, _ := NewClientWithOptions("test123", WithHTTPTransport(custom), WithTLSConfig(tlsConfig))
+
+	transport, ok := c.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", c.client.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != 7 || transport.TLSClientConfig != tlsConfig {
+		t.Errorf("Expected the custom transport with the TLS config, got %+v", transport)
+	}
+	if transport == custom || custom.TLSClientConfig == tlsConfig {
+		t.Error("Expected the TLS config to be applied to a copy of the supplied transport")
+	}
+}
+
+func TestWithHTTPClientTakesPrecedenceOverWithHTTPTransport(t *testing.T) {
+	httpClient := &http.Client{}
+	c, _ := NewClientWithOptions("test123", WithHTTPClient(httpClient), WithHTTPTransport(&recordingTransport{}))
+	if c.client != httpClient || httpClient.Transport != nil {
+		t.Error("Expected the supplied http.Client to be used as is")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer proxy.Close()
+
+	c, err := NewClientWithOptions("test123", WithBaseURL("http://api.example.invalid/"), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("Expected NewClientWithOptions not to return error, got %v", err)
+	}
+	err = c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if proxiedHost != "api.example.invalid" {
+		t.Errorf("Expected request for api.example.invalid through the proxy, got %q", proxiedHost)
+	}
+}
+
+func TestWithInvalidProxy(t *testing.T) {
+	for _, proxyUrl := range []string{"\x7f", "not a url"} {
+		c, err := NewClientWithOptions("test123", WithProxy(proxyUrl))
+		if err == nil {
+			t.Errorf("Expected NewClientWithOptions to return error for proxy %q, got nil", proxyUrl)
+		}
+		if c != nil {
+			t.Errorf("Expected NewClientWithOptions DNSClient to be nil for proxy %q, was not nil", proxyUrl)
+		}
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidRateLimit(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithRateLimit(0, 1)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
+
+func TestWithMaxConcurrency(t *testing.T) {
+	const limit = 3
+	var inFlight, maxInFlight int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithMaxConcurrency(limit))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: fmt.Sprintf("value%v", i)}
+			if err := c.CreateRecord(r, ""); err != nil {
+				t.Errorf("Expected CreateRecord not to return error, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if maxInFlight > limit {
+		t.Errorf("Expected at most %v requests in flight, got %v", limit, maxInFlight)
+	}
+}
+
+func TestWithMaxConcurrencyRespectsContext(t *testing.T) {
+	c, _ := NewClientWithOptions("test123", WithMaxConcurrency(1))
+	// Take the only slot, as a request in flight would
+	c.inFlight <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected CreateRecordContext to return context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidResponseSizeLimit(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithResponseSizeLimit(0)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidDefaultTTL(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithDefaultTTL(-1)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
+
+func TestNewClientWithOptionsWithEmptyCommand(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithAddCommand("")); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+	if _, err := NewClientWithOptions("test123", WithRemoveCommand("")); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
+
+func TestNewClientWithOptionsWithInvalidMaxConcurrency(t *testing.T) {
+	if _, err := NewClientWithOptions("test123", WithMaxConcurrency(0)); err == nil {
+		t.Error("expected NewClientWithOptions to return err, got nil")
+	}
+}
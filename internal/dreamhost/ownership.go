@@ -0,0 +1,55 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ownedComment returns the comment to create r with, stamped with the client's ownership marker if it has one.
+func (c *DNSClient) ownedComment(r DNSRecordValue) string {
+	if c.ownerMarker == "" || strings.Contains(r.Comment, c.ownerMarker) {
+		return r.Comment
+	}
+	if r.Comment == "" {
+		return c.ownerMarker
+	}
+	return r.Comment + " " + c.ownerMarker
+}
+
+// owns reports whether record carries the client's ownership marker.
+func (c *DNSClient) owns(record DNSRecord) bool {
+	return strings.Contains(record.Comment, c.ownerMarker)
+}
+
+// DeleteOwnedRecord deletes r only if its comment carries the ownership marker set by WithOwnershipMarker, returning
+// an error matching ErrRecordNotOwned for a record created by something else. This costs a list request to read the
+// record's comment. Deleting a record that doesn't exist succeeds, as with DeleteRecord.
+func (c *DNSClient) DeleteOwnedRecord(ctx context.Context, r DNSRecordValue, uniqueId string) error {
+	if c.ownerMarker == "" {
+		return errors.New("no ownership marker is configured, see WithOwnershipMarker")
+	}
+
+	record, found, err := c.GetRecordContext(ctx, r)
+	if err != nil {
+		return fmt.Errorf("failed to check the owner of %v record %v: %w", r.RecordType, r.Name, err)
+	}
+	if !found {
+		return nil
+	}
+	if !c.owns(*record) {
+		return fmt.Errorf("%w: %v record %v with value %v", ErrRecordNotOwned, r.RecordType, r.Name, r.Value)
+	}
+	return c.DeleteRecordContext(ctx, r, uniqueId)
+}
+
+// WithOwnershipMarker makes CreateRecord stamp every record it creates with marker in its comment, e.g.
+// "managed-by=cert-manager-webhook-dreamhost", so that DeleteOwnedRecord and CleanupOrphanedChallengeRecords leave
+// records written by other systems alone.
+func WithOwnershipMarker(marker string) Option {
+	return func(o *options) error {
+		o.client.ownerMarker = marker
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const ownershipListBody = `{
+	"data": [
+		{"account_id":"123456","comment":"managed-by=webhook","editable":"1","record":"_acme-challenge.example.com","type":"TXT","value":"owned","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.com","type":"TXT","value":"foreign","zone":"example.com"}
+	],
+	"result": "success"
+}`
+
+func newOwnershipServer() *recordingServer {
+	return newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return ownershipListBody
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+}
+
+func TestWithOwnershipMarkerStampsComment(t *testing.T) {
+	tests := map[string]string{
+		"":                             "managed-by=webhook",
+		"challenge":                    "challenge managed-by=webhook",
+		"challenge managed-by=webhook": "challenge managed-by=webhook",
+	}
+	for comment, expected := range tests {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			if actual := r.URL.Query().Get("comment"); actual != expected {
+				t.Errorf("Expected comment %q to become %q, got %q", comment, expected, actual)
+			}
+		})
+
+		c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithOwnershipMarker("managed-by=webhook"))
+		r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue", Comment: comment}
+		if err := c.CreateRecord(r, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+		svr.Close()
+	}
+}
+
+func TestDeleteOwnedRecord(t *testing.T) {
+	svr := newOwnershipServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithOwnershipMarker("managed-by=webhook"))
+	err := c.DeleteOwnedRecord(context.Background(), DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "owned"}, "")
+	if err != nil {
+		t.Errorf("Expected DeleteOwnedRecord not to return error, got %v", err)
+	}
+	expected := "[dns-list_records dns-remove_record owned]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestDeleteOwnedRecordRefusesForeignRecord(t *testing.T) {
+	svr := newOwnershipServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithOwnershipMarker("managed-by=webhook"))
+	err := c.DeleteOwnedRecord(context.Background(), DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "foreign"}, "")
+	if !errors.Is(err, ErrRecordNotOwned) {
+		t.Errorf("Expected DeleteOwnedRecord to return ErrRecordNotOwned, got %v", err)
+	}
+	expected := "[dns-list_records]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestDeleteOwnedRecordWithoutMarker(t *testing.T) {
+	c, _ := NewClientWithOptions("apikey123")
+	err := c.DeleteOwnedRecord(context.Background(), DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "owned"}, "")
+	if err == nil {
+		t.Error("Expected DeleteOwnedRecord to return error, got nil")
+	}
+}
+
+func TestCleanupOrphanedChallengeRecordsSparesForeignRecords(t *testing.T) {
+	svr := newOwnershipServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithOwnershipMarker("managed-by=webhook"))
+	if _, err := c.CleanupOrphanedChallengeRecords(context.Background(), "example.com", 0); err != nil {
+		t.Fatalf("Expected CleanupOrphanedChallengeRecords not to return error, got %v", err)
+	}
+	expected := "[dns-list_records dns-remove_record owned]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
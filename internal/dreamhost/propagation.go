@@ -0,0 +1,189 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const defaultResolverAddress = "ns1.dreamhost.com:53"
+const defaultPollInterval = 5 * time.Second
+const defaultPropagationWarnThreshold = 2 * time.Minute
+
+// Resolver looks up the TXT values published for a name. It is used by PollRecord to check whether a record has
+// propagated.
+type Resolver interface {
+	LookupTXT(ctx context.Context, fqdn string) ([]string, error)
+}
+
+// dnsResolver is a Resolver that queries a single DNS server directly, bypassing any caching in the system resolver.
+type dnsResolver struct {
+	address string
+	client  *dns.Client
+}
+
+// NewDNSResolver returns a Resolver that sends queries to the DNS server at address, e.g. "ns1.dreamhost.com:53".
+func NewDNSResolver(address string) Resolver {
+	return &dnsResolver{address: address, client: &dns.Client{}}
+}
+
+func (r *dnsResolver) LookupTXT(ctx context.Context, fqdn string) ([]string, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeTXT)
+
+	resp, _, err := r.client.ExchangeContext(ctx, msg, r.address)
+	if err != nil {
+		return nil, fmt.Errorf("TXT lookup of %v failed: %w", fqdn, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("TXT lookup of %v failed: %v", fqdn, dns.RcodeToString[resp.Rcode])
+	}
+
+	var values []string
+	for _, rr := range resp.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+		}
+	}
+	return values, nil
+}
+
+// PollStats describes how long a record took to become visible.
+type PollStats struct {
+	// Attempts is the number of lookups made.
+	Attempts int
+	// Waited is the total time spent polling.
+	Waited time.Duration
+}
+
+// PollRecord blocks until the TXT record r is visible through the configured resolver, or ctx is done. Lookup errors
+// are treated as the record not being visible yet.
+func (c *DNSClient) PollRecord(ctx context.Context, r DNSRecordValue) error {
+	_, err := c.PollRecordStats(ctx, r)
+	return err
+}
+
+// PollRecordStats is like PollRecord, but also reports how many lookups were made and how long they took, whether or
+// not the record became visible. If polling takes longer than the threshold set by WithPropagationWarnThreshold, a
+// warning is logged.
+func (c *DNSClient) PollRecordStats(ctx context.Context, r DNSRecordValue) (PollStats, error) {
+	if r.RecordType != "TXT" {
+		return PollStats{}, fmt.Errorf("cannot poll %v records, only TXT", r.RecordType)
+	}
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	warned := false
+	return c.poll(ctx, fmt.Sprintf("record %v not visible", r.Name), func() (bool, error) {
+		values, err := c.resolver.LookupTXT(ctx, r.Name)
+		if err != nil {
+			return false, err
+		}
+		for _, v := range values {
+			if v == r.sentValue(c.keepTXTQuotes) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, func(stats PollStats) {
+		if !warned && c.propagationWarnThreshold > 0 && stats.Waited > c.propagationWarnThreshold {
+			warned = true
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "dns record not yet propagated",
+				slog.String("record", r.Name),
+				slog.Int("attempts", stats.Attempts),
+				slog.Duration("waited", stats.Waited),
+			)
+		}
+	})
+}
+
+// WaitForDeletion blocks until r no longer appears in the account's records, or ctx is done. It lists the records
+// every poll interval, as set by WithPollInterval. List errors are treated as the record still being present.
+func (c *DNSClient) WaitForDeletion(ctx context.Context, r DNSRecordValue) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+	_, err := c.poll(ctx, fmt.Sprintf("%v record %v not deleted", r.RecordType, r.Name), func() (bool, error) {
+		records, err := c.listRecords(ctx, c.keyFor(&r))
+		if err != nil {
+			return false, err
+		}
+		for i := range records {
+			if recordsEqual(r, records[i], c.keepTXTQuotes) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, nil)
+	return err
+}
+
+// poll calls check every poll interval until it reports done, or ctx is done, in which case the error says what
+// didn't happen and includes the last error from check. progress, if not nil, is called after each check that isn't
+// done.
+func (c *DNSClient) poll(ctx context.Context, what string, check func() (bool, error), progress func(PollStats)) (PollStats, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var stats PollStats
+	var lastErr error
+	for {
+		done, err := check()
+		stats.Attempts++
+		stats.Waited = time.Since(start)
+		if done {
+			return stats, nil
+		}
+		lastErr = err
+		if progress != nil {
+			progress(stats)
+		}
+
+		select {
+		case <-ctx.Done():
+			stats.Waited = time.Since(start)
+			if lastErr != nil {
+				return stats, fmt.Errorf("%v (last error: %v): %w", what, lastErr, ctx.Err())
+			}
+			return stats, fmt.Errorf("%v: %w", what, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WithResolver sets the Resolver used by PollRecord. The default queries ns1.dreamhost.com directly.
+func WithResolver(resolver Resolver) Option {
+	return func(o *options) error {
+		o.client.resolver = resolver
+		return nil
+	}
+}
+
+// WithResolverAddress makes PollRecord query the DNS server at address, e.g. "8.8.8.8:53".
+func WithResolverAddress(address string) Option {
+	return WithResolver(NewDNSResolver(address))
+}
+
+// WithPollInterval sets how long PollRecord and WaitForDeletion wait between checks.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("poll interval must be positive, got %v", d)
+		}
+		o.client.pollInterval = d
+		return nil
+	}
+}
+
+// WithPropagationWarnThreshold sets how long PollRecord polls before logging a warning that the record hasn't
+// propagated yet, which is 2 minutes unless overridden. A d of 0 disables the warning.
+func WithPropagationWarnThreshold(d time.Duration) Option {
+	return func(o *options) error {
+		o.client.propagationWarnThreshold = d
+		return nil
+	}
+}
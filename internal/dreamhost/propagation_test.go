@@ -0,0 +1,189 @@
+package dreamhost
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// stubResolver returns values only once it has been queried visibleAfter times.
+type stubResolver struct {
+	mu           sync.Mutex
+	lookups      int
+	visibleAfter int
+	values       []string
+}
+
+func (s *stubResolver) LookupTXT(ctx context.Context, fqdn string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if s.lookups < s.visibleAfter {
+		return nil, nil
+	}
+	return s.values, nil
+}
+
+func TestPollRecord(t *testing.T) {
+	resolver := &stubResolver{visibleAfter: 3, values: []string{"otherValue", "testValue"}}
+	c, _ := NewClientWithOptions("test123", WithResolver(resolver), WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := c.PollRecord(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if err != nil {
+		t.Errorf("Expected PollRecord not to return error, got %v", err)
+	}
+	if resolver.lookups != 3 {
+		t.Errorf("Expected 3 lookups, got %v", resolver.lookups)
+	}
+}
+
+func TestPollRecordWithQuotedValue(t *testing.T) {
+	resolver := &stubResolver{values: []string{"testValue"}}
+	c, _ := NewClientWithOptions("test123", WithResolver(resolver), WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := c.PollRecord(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: `"testValue"`})
+	if err != nil {
+		t.Errorf("Expected PollRecord not to return error, got %v", err)
+	}
+}
+
+func TestPollRecordStats(t *testing.T) {
+	resolver := &stubResolver{visibleAfter: 4, values: []string{"testValue"}}
+	var buf bytes.Buffer
+	c, _ := NewClientWithOptions("test123", WithResolver(resolver), WithPollInterval(5*time.Millisecond),
+		WithPropagationWarnThreshold(time.Millisecond), WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := c.PollRecordStats(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if err != nil {
+		t.Fatalf("Expected PollRecordStats not to return error, got %v", err)
+	}
+	if stats.Attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %v", stats.Attempts)
+	}
+	if stats.Waited < 15*time.Millisecond {
+		t.Errorf("Expected to wait at least 3 poll intervals, got %v", stats.Waited)
+	}
+	if n := strings.Count(buf.String(), "dns record not yet propagated"); n != 1 {
+		t.Errorf("Expected a single propagation warning, got %v: %v", n, buf.String())
+	}
+}
+
+func TestPollRecordStatsWithoutWarning(t *testing.T) {
+	resolver := &stubResolver{visibleAfter: 1, values: []string{"testValue"}}
+	var buf bytes.Buffer
+	c, _ := NewClientWithOptions("test123", WithResolver(resolver), WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	stats, err := c.PollRecordStats(context.Background(), DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if err != nil {
+		t.Fatalf("Expected PollRecordStats not to return error, got %v", err)
+	}
+	if stats.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %v", stats.Attempts)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be logged, got %v", buf.String())
+	}
+}
+
+func TestPollRecordTimesOut(t *testing.T) {
+	resolver := &stubResolver{values: []string{"otherValue"}}
+	c, _ := NewClientWithOptions("test123", WithResolver(resolver), WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := c.PollRecord(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected PollRecord to return context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestPollRecordRejectsNonTXT(t *testing.T) {
+	c, _ := NewClientWithOptions("test123", WithResolver(&stubResolver{}))
+	if err := c.PollRecord(context.Background(), DNSRecordValue{Name: "example.com", RecordType: "A", Value: "192.0.2.1"}); err == nil {
+		t.Error("Expected PollRecord to return error, got nil")
+	}
+}
+
+func TestDNSResolverLookupTXT(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen for DNS queries: %v", err)
+	}
+	svr := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+		rr, _ := dns.NewRR(req.Question[0].Name + ` 60 IN TXT "testValue"`)
+		msg.Answer = append(msg.Answer, rr)
+		_ = w.WriteMsg(msg)
+	})}
+	go func() { _ = svr.ActivateAndServe() }()
+	defer func() { _ = svr.Shutdown() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := NewDNSResolver(pc.LocalAddr().String()).LookupTXT(ctx, "_acme-challenge.example.com")
+	if err != nil {
+		t.Fatalf("Expected LookupTXT not to return error, got %v", err)
+	}
+	if len(values) != 1 || values[0] != "testValue" {
+		t.Errorf("Expected values to be [testValue], got %v", values)
+	}
+}
+
+func TestWaitForDeletion(t *testing.T) {
+	var lists int32
+	svr := newRecordingServer(func(r *http.Request) string {
+		if atomic.AddInt32(&lists, 1) == 1 {
+			return listRecordsBody
+		}
+		return `{"result":"success","data":[]}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithPollInterval(time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := c.WaitForDeletion(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if err != nil {
+		t.Errorf("Expected WaitForDeletion not to return error, got %v", err)
+	}
+	if cmds := svr.commands(); len(cmds) != 2 {
+		t.Errorf("Expected 2 list requests, got %v", cmds)
+	}
+}
+
+func TestWaitForDeletionTimesOut(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("test123", WithBaseURL(svr.URL), WithPollInterval(time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := c.WaitForDeletion(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected WaitForDeletion to return context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package dreamhost
+
+import "context"
+
+// DNSProvider is the set of record operations offered by DNSClient, for code that wants to substitute a fake in its
+// tests.
+type DNSProvider interface {
+	CreateRecord(r DNSRecordValue, uniqueId string) error
+	CreateRecordContext(ctx context.Context, r DNSRecordValue, uniqueId string) error
+	DeleteRecord(r DNSRecordValue, uniqueId string) error
+	DeleteRecordContext(ctx context.Context, r DNSRecordValue, uniqueId string) error
+	ListRecords() ([]DNSRecord, error)
+	ListRecordsContext(ctx context.Context) ([]DNSRecord, error)
+	GetRecord(r DNSRecordValue) (*DNSRecord, bool, error)
+	GetRecordContext(ctx context.Context, r DNSRecordValue) (*DNSRecord, bool, error)
+}
+
+var _ DNSProvider = (*DNSClient)(nil)
@@ -0,0 +1,331 @@
+package dreamhost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DNSRecord is a DNS record as returned by the dns-list_records command.
+type DNSRecord struct {
+	AccountID string `json:"account_id"`
+	Zone      string `json:"zone"`
+	Record    string `json:"record"`
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Comment   string `json:"comment"`
+	// Editable is false for records managed by DreamHost itself, which cannot be changed through the API.
+	Editable bool `json:"editable"`
+}
+
+// UnmarshalJSON decodes a record, converting the API's "0"/"1" editable flag to a bool.
+func (r *DNSRecord) UnmarshalJSON(data []byte) error {
+	type record DNSRecord
+	aux := struct {
+		*record
+		Editable string `json:"editable"`
+	}{record: (*record)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Editable = aux.Editable == "1"
+	return nil
+}
+
+// RecordsEqual reports whether the listed record b is the record a describes, ignoring differences DreamHost doesn't
+// care about: names are compared case-insensitively, ignoring the trailing dot of a fully-qualified name and with
+// Unicode labels matching their punycode, and a's value is compared as the client sends it by default, so a quoted TXT
+// value matches the unquoted value stored.
+func RecordsEqual(a DNSRecordValue, b DNSRecord) bool {
+	return recordsEqual(a, b, false)
+}
+
+// recordsEqual is RecordsEqual for a client that keeps TXT quotes if keepTXTQuotes is set. Listed records are compared
+// by their stored value, verbatim.
+func recordsEqual(a DNSRecordValue, b DNSRecord, keepTXTQuotes bool) bool {
+	return keyOf(a, keepTXTQuotes) == keyOf(storedValue(b), keepTXTQuotes)
+}
+
+// sameNameAndType is like recordsEqual, but ignores the values.
+func sameNameAndType(a DNSRecordValue, b DNSRecord) bool {
+	aKey, bKey := keyOf(a, false), keyOf(storedValue(b), false)
+	return aKey.name == bKey.name && aKey.recordType == bKey.recordType
+}
+
+// storedValue returns the DNSRecordValue of a listed record, whose value is sent and compared verbatim, e.g. to delete
+// a TXT value that really does have surrounding quotes.
+func storedValue(record DNSRecord) DNSRecordValue {
+	return DNSRecordValue{Name: record.Record, RecordType: record.Type, Value: record.Value, stored: true}
+}
+
+// ListRecords lists all DNS records in the account.
+//
+// Example GET request:
+// https://api.dreamhost.com/?key=1A2B3C4D5E6F7G8H&cmd=dns-list_records&format=json
+func (c *DNSClient) ListRecords() ([]DNSRecord, error) {
+	return c.ListRecordsContext(context.Background())
+}
+
+// ListRecordsContext is like ListRecords, but the request is bound to ctx.
+func (c *DNSClient) ListRecordsContext(ctx context.Context) ([]DNSRecord, error) {
+	return c.listRecords(ctx, c.apiKey)
+}
+
+// listRecords lists the DNS records in the account of the given API key. With WithListCoalescing, concurrent calls for
+// the same key share a single request.
+func (c *DNSClient) listRecords(ctx context.Context, key string) ([]DNSRecord, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	if c.listCalls == nil {
+		return c.fetchRecords(ctx, key)
+	}
+	shared, err, _ := c.listCalls.Do(key, func() (any, error) {
+		return c.fetchRecords(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Every caller gets its own copy, so that one modifying its records doesn't affect the others
+	return append([]DNSRecord(nil), shared.([]DNSRecord)...), nil
+}
+
+// fetchRecords sends a dns-list_records request with the given API key.
+func (c *DNSClient) fetchRecords(ctx context.Context, key string) (records []DNSRecord, err error) {
+	ctx, requestId := ensureRequestID(ctx)
+	start := time.Now()
+	defer func() {
+		err = withRequestID(err, requestId)
+		c.logRequest(ctx, slog.LevelDebug, listRecordsCmd, nil, start, err)
+		c.observeRequest(listRecordsCmd, start, err)
+	}()
+
+	body, _, err := c.fetch(ctx, key, nil, listRecordsCmd, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// The API returns every record in one response. Should it ever page its results, the remaining pages can be
+	// fetched and appended here without callers noticing.
+	resp, err := c.parseResponse(listRecordsCmd, body)
+	if err != nil {
+		return nil, err
+	}
+	list, err := resp.DataList()
+	if err != nil {
+		return nil, c.parseError(body, err)
+	}
+	records = make([]DNSRecord, 0, len(list))
+	for _, item := range list {
+		var record DNSRecord
+		if err := json.Unmarshal(item, &record); err != nil {
+			return nil, c.parseError(body, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ListRecordsForZone lists the DNS records in the account whose name is zone or a subdomain of it, comparing names
+// case-insensitively and ignoring any trailing dot. For example, the zone example.com includes foo.example.com, but not
+// notexample.com. With a KeyResolver, the records are listed with the zone's API key.
+func (c *DNSClient) ListRecordsForZone(zone string) ([]DNSRecord, error) {
+	return c.listRecordsForZone(context.Background(), zone)
+}
+
+func (c *DNSClient) listRecordsForZone(ctx context.Context, zone string) ([]DNSRecord, error) {
+	records, err := c.listRecords(ctx, c.keyFor(&DNSRecordValue{Name: zone}))
+	if err != nil {
+		return nil, err
+	}
+	var inZoneRecords []DNSRecord
+	for _, record := range records {
+		if inZone(record.Record, zone) {
+			inZoneRecords = append(inZoneRecords, record)
+		}
+	}
+	return inZoneRecords, nil
+}
+
+// ListRecordsGroupedByName lists all DNS records in the account, like ListRecords, keyed by their name as listed, e.g.
+// to see every TXT value of a challenge name at once. Records of a name keep the order they were listed in.
+func (c *DNSClient) ListRecordsGroupedByName() (map[string][]DNSRecord, error) {
+	records, err := c.ListRecords()
+	if err != nil {
+		return nil, err
+	}
+	grouped := make(map[string][]DNSRecord)
+	for _, record := range records {
+		grouped[record.Record] = append(grouped[record.Record], record)
+	}
+	return grouped, nil
+}
+
+// GetRecord returns the record matching r's name, type and value, comparing names case-insensitively. If there is no
+// such record, found is false and err is nil. With a KeyResolver, the records are listed with r's API key.
+func (c *DNSClient) GetRecord(r DNSRecordValue) (record *DNSRecord, found bool, err error) {
+	return c.GetRecordContext(context.Background(), r)
+}
+
+// GetRecordContext is like GetRecord, but the request, if any, is bound to ctx.
+func (c *DNSClient) GetRecordContext(ctx context.Context, r DNSRecordValue) (record *DNSRecord, found bool, err error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+	records, err := c.cachedRecords(ctx, c.keyFor(&r))
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range records {
+		if recordsEqual(r, records[i], c.keepTXTQuotes) {
+			return &records[i], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// CreateRecordIfAbsent creates r unless a record with the same name, type and value already exists, saving a write
+// when it does. A uniqueId string may optionally be provided for idempotency.
+func (c *DNSClient) CreateRecordIfAbsent(r DNSRecordValue, uniqueId string) error {
+	ctx, cancel := c.operationContext(context.Background())
+	defer cancel()
+	_, found, err := c.GetRecordContext(ctx, r)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing %v record %v: %w", r.RecordType, r.Name, err)
+	}
+	if found {
+		return nil
+	}
+	return c.CreateRecordContext(ctx, r, uniqueId)
+}
+
+// EditRecord replaces old with new. The API has no edit command, so old is deleted and new is created; if creating new
+// fails, old is restored. A uniqueId string may optionally be provided for idempotency, from which distinct ids are
+// derived for each of the underlying requests.
+func (c *DNSClient) EditRecord(old, new DNSRecordValue, uniqueId string) error {
+	if err := c.DeleteRecord(old, deriveStepId(uniqueId, "remove")); err != nil {
+		return fmt.Errorf("failed to delete %v record %v with value %v: %w", old.RecordType, old.Name, old.Value, err)
+	}
+
+	addErr := c.CreateRecord(new, deriveStepId(uniqueId, "add"))
+	if addErr == nil {
+		return nil
+	}
+
+	if err := c.CreateRecord(old, deriveStepId(uniqueId, "rollback")); err != nil {
+		return fmt.Errorf("inconsistent state: deleted %v record %v with value %v, failed to create value %v (%w), "+
+			"and failed to restore the old value (%w)", old.RecordType, old.Name, old.Value, new.Value, addErr, err)
+	}
+	return fmt.Errorf("failed to create %v record %v with value %v, restored value %v: %w",
+		new.RecordType, new.Name, new.Value, old.Value, addErr)
+}
+
+// RotateTXT replaces the TXT value oldValue of name with newValue, such as a challenge value, without a window in which
+// the name has neither: newValue is created and verified to be listed before oldValue is deleted. If newValue can't be
+// verified, it is deleted again and oldValue is kept.
+func (c *DNSClient) RotateTXT(name, oldValue, newValue string) error {
+	ctx, cancel := c.operationContext(context.Background())
+	defer cancel()
+	old := DNSRecordValue{Name: name, RecordType: "TXT", Value: oldValue}
+	new := DNSRecordValue{Name: name, RecordType: "TXT", Value: newValue}
+
+	if err := c.CreateRecordContext(ctx, new, ""); err != nil {
+		return fmt.Errorf("failed to create TXT record %v with value %v: %w", name, newValue, err)
+	}
+
+	_, found, verifyErr := c.GetRecordContext(ctx, new)
+	if verifyErr == nil && !found {
+		verifyErr = errors.New("record not listed after creating it")
+	}
+	if verifyErr != nil {
+		if err := c.DeleteRecordContext(ctx, new, ""); err != nil {
+			return fmt.Errorf("inconsistent state: failed to verify TXT record %v with value %v (%w), and failed to delete "+
+				"it again (%w)", name, newValue, verifyErr, err)
+		}
+		return fmt.Errorf("failed to verify TXT record %v with value %v, deleted it again: %w", name, newValue, verifyErr)
+	}
+
+	if err := c.DeleteRecordContext(ctx, old, ""); err != nil {
+		return fmt.Errorf("created TXT record %v with value %v, but failed to delete value %v: %w", name, newValue, oldValue, err)
+	}
+	return nil
+}
+
+// ReplaceRecord ensures that r is the only value of its name and type: r is created unless it already exists, and only
+// then are any other values deleted, so that the name is never left without a value, e.g. for a challenge. If creating
+// r fails, the other values are kept. A uniqueId string may optionally be provided for idempotency, from which distinct
+// ids are derived for each of the underlying requests.
+func (c *DNSClient) ReplaceRecord(r DNSRecordValue, uniqueId string) error {
+	ctx, cancel := c.operationContext(context.Background())
+	defer cancel()
+	records, err := c.cachedRecords(ctx, c.keyFor(&r))
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	var current []DNSRecordValue
+	for _, record := range records {
+		if sameNameAndType(r, record) {
+			current = append(current, storedValue(record))
+		}
+	}
+
+	toAdd, toDelete := diffRecords(current, []DNSRecordValue{r}, c.keepTXTQuotes)
+	if len(toAdd) > 0 {
+		if err := c.CreateRecordContext(ctx, r, deriveStepId(uniqueId, "add")); err != nil {
+			return err
+		}
+	}
+
+	for i, stale := range toDelete {
+		if err := c.DeleteRecordContext(ctx, stale, deriveStepId(uniqueId, fmt.Sprintf("remove%v", i+1))); err != nil {
+			return fmt.Errorf("failed to delete stale %v record %v with value %v: %w", stale.RecordType, stale.Name, stale.Value, err)
+		}
+	}
+	return nil
+}
+
+// DiffRecords returns the records to add and to delete to turn the current set of records into the desired set.
+// Records are compared by name, type and value, with names compared as DNS names, so a record whose value differs is
+// both deleted and added. TXT values are compared without surrounding quotes, as the client sends them by default.
+// Duplicates are only added or deleted once, and records are returned in the order given.
+func DiffRecords(current, desired []DNSRecordValue) (toAdd, toDelete []DNSRecordValue) {
+	return diffRecords(current, desired, false)
+}
+
+func diffRecords(current, desired []DNSRecordValue, keepTXTQuotes bool) (toAdd, toDelete []DNSRecordValue) {
+	currentKeys := make(map[recordKey]bool, len(current))
+	for _, r := range current {
+		currentKeys[keyOf(r, keepTXTQuotes)] = true
+	}
+	desiredKeys := make(map[recordKey]bool, len(desired))
+	for _, r := range desired {
+		desiredKeys[keyOf(r, keepTXTQuotes)] = true
+	}
+
+	seen := make(map[recordKey]bool)
+	for _, r := range desired {
+		if key := keyOf(r, keepTXTQuotes); !currentKeys[key] && !seen[key] {
+			seen[key] = true
+			toAdd = append(toAdd, r)
+		}
+	}
+	for _, r := range current {
+		if key := keyOf(r, keepTXTQuotes); !desiredKeys[key] && !seen[key] {
+			seen[key] = true
+			toDelete = append(toDelete, r)
+		}
+	}
+	return toAdd, toDelete
+}
+
+// deriveStepId derives a unique_id for one step of a multi-request operation, since each request needs its own id.
+func deriveStepId(uniqueId string, step string) string {
+	if uniqueId == "" {
+		return ""
+	}
+	return uniqueId + "-" + step
+}
@@ -0,0 +1,749 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const listRecordsBody = `{
+	"data": [
+		{"account_id":"123456","comment":"","editable":"0","record":"example.com","type":"A","value":"192.0.2.1","zone":"example.com"},
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.com","type":"TXT","value":"testValue","zone":"example.com"},
+		{"account_id":"123456","comment":"mail","editable":"1","record":"example.com","type":"MX","value":"0 mx1.example.com.","zone":"example.com"}
+	],
+	"result": "success"
+}`
+
+func TestListRecords(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, func(r *http.Request) {
+		q := r.URL.Query()
+		if actual := q.Get("cmd"); actual != "dns-list_records" {
+			t.Errorf("Expected cmd to be dns-list_records, got %v", actual)
+		}
+		if q.Has("record") {
+			t.Errorf("Expected record to not be present, got %v", q.Get("record"))
+		}
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	records, err := c.ListRecords()
+	if err != nil {
+		t.Fatalf("Expected ListRecords not to return error, got %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %v", len(records))
+	}
+
+	expected := DNSRecord{
+		AccountID: "123456",
+		Zone:      "example.com",
+		Record:    "_acme-challenge.example.com",
+		Type:      "TXT",
+		Value:     "testValue",
+		Editable:  true,
+	}
+	if records[1] != expected {
+		t.Errorf("Expected record to be %+v, got %+v", expected, records[1])
+	}
+	if records[0].Editable {
+		t.Error("Expected first record not to be editable")
+	}
+	if records[2].Comment != "mail" {
+		t.Errorf("Expected comment to be mail, got %v", records[2].Comment)
+	}
+}
+
+func TestListRecordsContextCancelled(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer svr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	start := time.Now()
+	_, err := c.ListRecordsContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ListRecordsContext to return context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected ListRecordsContext to return promptly, took %v", elapsed)
+	}
+}
+
+func TestGetRecordContextCancelled(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	_, _, err := c.GetRecordContext(ctx, DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected GetRecordContext to return context.Canceled, got %v", err)
+	}
+}
+
+func TestListRecordsErrorResponse(t *testing.T) {
+	expectedErrContent := "dreamhost API returned non-successful result"
+
+	svr := mockHttpResponse(200, `{"result":"error","data":"invalid_api_key"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.ListRecords(); err == nil {
+		t.Error("Expected ListRecords to return error, got nil")
+	} else if !strings.Contains(err.Error(), expectedErrContent) {
+		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
+	}
+}
+
+func TestListRecordsInvalidResponse(t *testing.T) {
+	expectedErrContent := "failed to parse response"
+
+	svr := mockHttpResponse(200, `{"result":"success","data":"not a list"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if _, err := c.ListRecords(); err == nil {
+		t.Error("Expected ListRecords to return error, got nil")
+	} else if !strings.Contains(err.Error(), expectedErrContent) {
+		t.Errorf("Expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	record, found, err := c.GetRecord(DNSRecordValue{Name: "_ACME-Challenge.Example.com", RecordType: "TXT", Value: "testValue"})
+	if err != nil {
+		t.Fatalf("Expected GetRecord not to return error, got %v", err)
+	}
+	if !found {
+		t.Fatal("Expected GetRecord to find the record")
+	}
+	if record.Record != "_acme-challenge.example.com" || !record.Editable {
+		t.Errorf("Expected the TXT record, got %+v", record)
+	}
+}
+
+func TestGetRecordWithTrailingDot(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	_, found, err := c.GetRecord(DNSRecordValue{Name: "_acme-challenge.example.com.", RecordType: "TXT", Value: "testValue"})
+	if err != nil || !found {
+		t.Errorf("Expected GetRecord to find the record, got found %v and err %v", found, err)
+	}
+}
+
+func TestGetRecordWithQuotedTXTValue(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	_, found, err := c.GetRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: `"testValue"`})
+	if err != nil || !found {
+		t.Errorf("Expected GetRecord to find the record, got found %v and err %v", found, err)
+	}
+
+	c, _ = NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithLiteralTXTQuotes())
+	_, found, err = c.GetRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: `"testValue"`})
+	if err != nil || found {
+		t.Errorf("Expected GetRecord with WithLiteralTXTQuotes not to find the record, got found %v and err %v", found, err)
+	}
+}
+
+func TestGetRecordWithInternationalizedName(t *testing.T) {
+	body := `{"result":"success","data":[{"account_id":"1","zone":"xn--bcher-kva.example",` +
+		`"record":"_acme-challenge.xn--bcher-kva.example","type":"TXT","value":"testValue","comment":"","editable":"1"}]}`
+	svr := mockHttpResponse(200, body, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	_, found, err := c.GetRecord(DNSRecordValue{Name: "_acme-challenge.bücher.example", RecordType: "TXT", Value: "testValue"})
+	if err != nil || !found {
+		t.Errorf("Expected GetRecord to find the record, got found %v and err %v", found, err)
+	}
+}
+
+func TestListRecordsGroupedByName(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	grouped, err := c.ListRecordsGroupedByName()
+	if err != nil {
+		t.Fatalf("Expected ListRecordsGroupedByName not to return error, got %v", err)
+	}
+	if len(grouped) != 2 {
+		t.Errorf("Expected 2 names, got %v", len(grouped))
+	}
+	if apex := grouped["example.com"]; len(apex) != 2 || apex[0].Type != "A" || apex[1].Type != "MX" {
+		t.Errorf("Expected the A and MX records of example.com, got %+v", apex)
+	}
+	if challenge := grouped["_acme-challenge.example.com"]; len(challenge) != 1 || challenge[0].Value != "testValue" {
+		t.Errorf("Expected the TXT record of _acme-challenge.example.com, got %+v", challenge)
+	}
+}
+
+func TestRecordsEqual(t *testing.T) {
+	listed := DNSRecord{Record: "_acme-challenge.example.com", Type: "TXT", Value: "testValue"}
+	tests := []struct {
+		name     string
+		a        DNSRecordValue
+		b        DNSRecord
+		expected bool
+	}{
+		{"identical", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}, listed, true},
+		{"trailing dot", DNSRecordValue{Name: "_acme-challenge.example.com.", RecordType: "TXT", Value: "testValue"}, listed, true},
+		{"name case", DNSRecordValue{Name: "_ACME-Challenge.Example.COM", RecordType: "TXT", Value: "testValue"}, listed, true},
+		{"quoted TXT value", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: `"testValue"`}, listed, true},
+		{"Unicode name", DNSRecordValue{Name: "bücher.example", RecordType: "TXT", Value: "testValue"},
+			DNSRecord{Record: "xn--bcher-kva.example", Type: "TXT", Value: "testValue"}, true},
+		{"different value", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "otherValue"}, listed, false},
+		{"value case", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "TESTVALUE"}, listed, false},
+		{"different type", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "CNAME", Value: "testValue"}, listed, false},
+		{"different name", DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, listed, false},
+		{"stored value with quotes", DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "abc"},
+			DNSRecord{Record: "_acme-challenge.example.com", Type: "TXT", Value: `"abc"`}, false},
+		{"quoted non-TXT value", DNSRecordValue{Name: "example.com", RecordType: "A", Value: `"192.0.2.1"`},
+			DNSRecord{Record: "example.com", Type: "A", Value: "192.0.2.1"}, false},
+	}
+	for _, test := range tests {
+		if actual := RecordsEqual(test.a, test.b); actual != test.expected {
+			t.Errorf("%v: Expected RecordsEqual to be %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestGetRecordNotFound(t *testing.T) {
+	svr := mockHttpResponse(200, listRecordsBody, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	// Same name and type as an existing record, but a different value
+	record, found, err := c.GetRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "otherValue"})
+	if err != nil {
+		t.Fatalf("Expected GetRecord not to return error, got %v", err)
+	}
+	if found || record != nil {
+		t.Errorf("Expected GetRecord not to find a record, got %+v", record)
+	}
+}
+
+func TestGetRecordErrorResponse(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"error","data":"invalid_api_key"}`, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	_, found, err := c.GetRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"})
+	if !errors.Is(err, ErrNonSuccessResult) {
+		t.Errorf("Expected GetRecord to return ErrNonSuccessResult, got %v", err)
+	}
+	if found {
+		t.Error("Expected found to be false on error")
+	}
+}
+
+func TestListRecordsLargeResponse(t *testing.T) {
+	const count = 20000
+	var body strings.Builder
+	body.WriteString(`{"result":"success","data":[`)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"account_id":"123456","comment":"","editable":"1","record":"host%v.example.com","type":"A","value":"192.0.2.1","zone":"example.com"}`, i)
+	}
+	body.WriteString(`]}`)
+
+	svr := mockHttpResponse(200, body.String(), nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	records, err := c.ListRecords()
+	if err != nil {
+		t.Fatalf("Expected ListRecords not to return error, got %v", err)
+	}
+	if len(records) != count {
+		t.Fatalf("Expected %v records, got %v", count, len(records))
+	}
+	if records[count-1].Record != fmt.Sprintf("host%v.example.com", count-1) {
+		t.Errorf("Expected the last record to be decoded, got %+v", records[count-1])
+	}
+}
+
+func TestListRecordsResponseTooLarge(t *testing.T) {
+	body := `{"result":"success","data":["` + strings.Repeat("x", 1024) + `"]}`
+	svr := mockHttpResponse(200, body, nil)
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithResponseSizeLimit(1024))
+	if _, err := c.ListRecords(); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ListRecords to return ErrResponseTooLarge, got %v", err)
+	}
+}
+
+// recordingServer responds to each command with the body returned by respond, and records the requests it received.
+type recordingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newRecordingServer(respond func(r *http.Request) string) *recordingServer {
+	rs := &recordingServer{}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.mu.Lock()
+		rs.requests = append(rs.requests, r)
+		rs.mu.Unlock()
+		_, _ = fmt.Fprint(w, respond(r))
+	}))
+	return rs
+}
+
+// commands returns the cmd and value of each request received, e.g. "dns-add_record newValue".
+func (rs *recordingServer) commands() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var cmds []string
+	for _, r := range rs.requests {
+		cmds = append(cmds, strings.TrimSpace(r.URL.Query().Get("cmd")+" "+r.URL.Query().Get("value")))
+	}
+	return cmds
+}
+
+func TestRotateTXT(t *testing.T) {
+	tests := map[string]struct {
+		listed   string
+		expected string
+		fails    bool
+	}{
+		"verified": {
+			"newValue",
+			"[dns-add_record newValue dns-list_records dns-remove_record testValue]",
+			false,
+		},
+		"not verified": {
+			"testValue",
+			"[dns-add_record newValue dns-list_records dns-remove_record newValue]",
+			true,
+		},
+	}
+	for name, test := range tests {
+		svr := newRecordingServer(func(r *http.Request) string {
+			if r.URL.Query().Get("cmd") == "dns-list_records" {
+				return `{"result":"success","data":[{"account_id":"1","zone":"example.com","record":"_acme-challenge.example.com",` +
+					`"type":"TXT","value":"` + test.listed + `","comment":"","editable":"1"}]}`
+			}
+			return `{"result":"success","data":"ok"}`
+		})
+
+		c, _ := NewClient("apikey123", nil, svr.URL)
+		err := c.RotateTXT("_acme-challenge.example.com", "testValue", "newValue")
+		if test.fails && err == nil {
+			t.Errorf("%v: Expected RotateTXT to return error, got nil", name)
+		}
+		if !test.fails && err != nil {
+			t.Errorf("%v: Expected RotateTXT not to return error, got %v", name, err)
+		}
+		if actual := fmt.Sprint(svr.commands()); actual != test.expected {
+			t.Errorf("%v: Expected commands %v, got %v", name, test.expected, actual)
+		}
+		svr.Close()
+	}
+}
+
+func TestEditRecord(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.EditRecord(
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "oldValue"},
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "newValue"},
+		"unique123",
+	)
+	if err != nil {
+		t.Errorf("Expected EditRecord not to return error, got %v", err)
+	}
+
+	expected := "[dns-remove_record oldValue dns-add_record newValue]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+	for _, r := range svr.requests {
+		if id := r.URL.Query().Get("unique_id"); id == "unique123" {
+			t.Error("Expected each step to use a unique_id derived from unique123, not unique123 itself")
+		}
+	}
+}
+
+func TestEditRecordRollsBackWhenCreateFails(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("value") == "newValue" {
+			return `{"result":"error","data":"invalid_record"}`
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.EditRecord(
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "oldValue"},
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "newValue"},
+		"",
+	)
+	if err == nil {
+		t.Error("Expected EditRecord to return error, got nil")
+	} else if strings.Contains(err.Error(), "inconsistent state") {
+		t.Errorf("Expected successful rollback not to report an inconsistent state, got %v", err)
+	}
+
+	expected := "[dns-remove_record oldValue dns-add_record newValue dns-add_record oldValue]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestEditRecordReportsInconsistentState(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-add_record" {
+			return `{"result":"error","data":"internal_error"}`
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.EditRecord(
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "oldValue"},
+		DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "newValue"},
+		"",
+	)
+	if err == nil {
+		t.Fatal("Expected EditRecord to return error, got nil")
+	}
+	for _, expected := range []string{"inconsistent state", "oldValue", "newValue"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected err to contain %v, but was %v instead", expected, err.Error())
+		}
+	}
+}
+
+func TestCreateRecordIfAbsent(t *testing.T) {
+	tests := map[string]struct {
+		record   DNSRecordValue
+		expected string
+	}{
+		"present": {
+			DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"},
+			"[dns-list_records]",
+		},
+		"absent": {
+			DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "newValue"},
+			"[dns-list_records dns-add_record newValue]",
+		},
+		"present quoted": {
+			DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: `"testValue"`},
+			"[dns-list_records]",
+		},
+	}
+	for name, test := range tests {
+		svr := newRecordingServer(func(r *http.Request) string {
+			if r.URL.Query().Get("cmd") == "dns-list_records" {
+				return listRecordsBody
+			}
+			return `{"result":"success","data":"record_added"}`
+		})
+
+		c, _ := NewClient("apikey123", nil, svr.URL)
+		if err := c.CreateRecordIfAbsent(test.record, ""); err != nil {
+			t.Errorf("%v: Expected CreateRecordIfAbsent not to return error, got %v", name, err)
+		}
+		if actual := fmt.Sprint(svr.commands()); actual != test.expected {
+			t.Errorf("%v: Expected commands %v, got %v", name, test.expected, actual)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordIfAbsentCheckFails(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		return `{"result":"error","data":"invalid_api_key"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecordIfAbsent(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "newValue"}, "")
+	if !errors.Is(err, ErrNonSuccessResult) {
+		t.Errorf("Expected CreateRecordIfAbsent to return ErrNonSuccessResult, got %v", err)
+	}
+	if expected := "[dns-list_records]"; fmt.Sprint(svr.commands()) != expected {
+		t.Errorf("Expected commands %v, got %v", expected, svr.commands())
+	}
+}
+
+func TestReplaceRecord(t *testing.T) {
+	tests := map[string]struct {
+		record   DNSRecordValue
+		expected string
+	}{
+		"nothing exists": {
+			DNSRecordValue{Name: "_acme-challenge.example.org", RecordType: "TXT", Value: "newValue"},
+			"[dns-list_records dns-add_record newValue]",
+		},
+		"stale value exists": {
+			DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "newValue"},
+			"[dns-list_records dns-add_record newValue dns-remove_record testValue]",
+		},
+		"exact value exists": {
+			DNSRecordValue{Name: "_ACME-Challenge.example.com", RecordType: "TXT", Value: "testValue"},
+			"[dns-list_records]",
+		},
+	}
+	for name, test := range tests {
+		svr := newRecordingServer(func(r *http.Request) string {
+			if r.URL.Query().Get("cmd") == "dns-list_records" {
+				return listRecordsBody
+			}
+			return `{"result":"success","data":"ok"}`
+		})
+
+		c, _ := NewClient("apikey123", nil, svr.URL)
+		if err := c.ReplaceRecord(test.record, "unique123"); err != nil {
+			t.Errorf("%v: Expected ReplaceRecord not to return error, got %v", name, err)
+		}
+		if actual := fmt.Sprint(svr.commands()); actual != test.expected {
+			t.Errorf("%v: Expected commands %v, got %v", name, test.expected, actual)
+		}
+		svr.Close()
+	}
+}
+
+const quotedListBody = `{
+	"data": [
+		{"account_id":"123456","comment":"","editable":"1","record":"_acme-challenge.example.com","type":"TXT","value":"\"abc\"","zone":"example.com"}
+	],
+	"result": "success"
+}`
+
+func TestReplaceRecordDeletesQuotedStoredValue(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return quotedListBody
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	if err := c.ReplaceRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "abc"}, ""); err != nil {
+		t.Errorf("Expected ReplaceRecord not to return error, got %v", err)
+	}
+	expected := `[dns-list_records dns-add_record abc dns-remove_record "abc"]`
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestReplaceRecordStopsWhenDeleteFails(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		switch r.URL.Query().Get("cmd") {
+		case "dns-list_records":
+			return listRecordsBody
+		case "dns-remove_record":
+			return `{"result":"error","data":"internal_error"}`
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.ReplaceRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "newValue"}, "")
+	if err == nil {
+		t.Error("Expected ReplaceRecord to return error, got nil")
+	}
+	expected := "[dns-list_records dns-add_record newValue dns-remove_record testValue]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestReplaceRecordKeepsStaleValuesWhenCreateFails(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		switch r.URL.Query().Get("cmd") {
+		case "dns-list_records":
+			return listRecordsBody
+		case "dns-add_record":
+			return `{"result":"error","data":"internal_error"}`
+		}
+		return `{"result":"success","data":"ok"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.ReplaceRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "newValue"}, "")
+	if err == nil {
+		t.Error("Expected ReplaceRecord to return error, got nil")
+	}
+	expected := "[dns-list_records dns-add_record newValue]"
+	if actual := fmt.Sprint(svr.commands()); actual != expected {
+		t.Errorf("Expected commands %v, got %v", expected, actual)
+	}
+}
+
+func TestDeleteRecordWithEditableCheck(t *testing.T) {
+	tests := map[string]struct {
+		record   DNSRecordValue
+		err      error
+		expected string
+	}{
+		"editable": {
+			DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"},
+			nil,
+			"[dns-list_records dns-remove_record testValue]",
+		},
+		"not editable": {
+			DNSRecordValue{Name: "example.com", RecordType: "A", Value: "192.0.2.1"},
+			ErrRecordNotEditable,
+			"[dns-list_records]",
+		},
+		"not found": {
+			DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "missing"},
+			nil,
+			"[dns-list_records dns-remove_record missing]",
+		},
+	}
+	for name, test := range tests {
+		svr := newRecordingServer(func(r *http.Request) string {
+			if r.URL.Query().Get("cmd") == "dns-list_records" {
+				return listRecordsBody
+			}
+			return `{"result":"success","data":"record_removed"}`
+		})
+
+		c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithEditableCheck())
+		err := c.DeleteRecord(test.record, "")
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: Expected DeleteRecord to return %v, got %v", name, test.err, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), test.record.Name) {
+			t.Errorf("%v: Expected err to name the record, got %v", name, err)
+		}
+		if actual := fmt.Sprint(svr.commands()); actual != test.expected {
+			t.Errorf("%v: Expected commands %v, got %v", name, test.expected, actual)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordSuppressesExistingMatchingRecord(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return listRecordsBody
+		}
+		return `{"result":"error","data":"record_already_exists_remove_first"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "_ACME-challenge.example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+}
+
+func TestCreateRecordReportsExistingConflictingRecord(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string {
+		if r.URL.Query().Get("cmd") == "dns-list_records" {
+			return listRecordsBody
+		}
+		return `{"result":"error","data":"record_already_exists_remove_first"}`
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	err := c.CreateRecord(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "otherValue"}, "")
+	if !errors.Is(err, ErrRecordExists) {
+		t.Errorf("Expected CreateRecord to return ErrRecordExists, got %v", err)
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	a := DNSRecordValue{Name: "example.com", RecordType: "A", Value: "192.0.2.1"}
+	txt1 := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "value1"}
+	txt2 := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "value2"}
+
+	tests := []struct {
+		name             string
+		current, desired []DNSRecordValue
+		toAdd, toDelete  []DNSRecordValue
+	}{
+		{"addition", []DNSRecordValue{a}, []DNSRecordValue{a, txt1}, []DNSRecordValue{txt1}, nil},
+		{"deletion", []DNSRecordValue{a, txt1}, []DNSRecordValue{a}, nil, []DNSRecordValue{txt1}},
+		{"no-op", []DNSRecordValue{a, txt1}, []DNSRecordValue{txt1, a}, nil, nil},
+		{"different value", []DNSRecordValue{txt1}, []DNSRecordValue{txt2}, []DNSRecordValue{txt2}, []DNSRecordValue{txt1}},
+		{"duplicates", []DNSRecordValue{txt1, txt1}, []DNSRecordValue{txt2, txt2}, []DNSRecordValue{txt2}, []DNSRecordValue{txt1}},
+		{"name case and trailing dot", []DNSRecordValue{{Name: "Example.com.", RecordType: "A", Value: "192.0.2.1"}},
+			[]DNSRecordValue{a}, nil, nil},
+	}
+	for _, test := range tests {
+		toAdd, toDelete := DiffRecords(test.current, test.desired)
+		if !reflect.DeepEqual(toAdd, test.toAdd) {
+			t.Errorf("%v: Expected records to add to be %+v, got %+v", test.name, test.toAdd, toAdd)
+		}
+		if !reflect.DeepEqual(toDelete, test.toDelete) {
+			t.Errorf("%v: Expected records to delete to be %+v, got %+v", test.name, test.toDelete, toDelete)
+		}
+	}
+}
+
+func TestListRecordsForZone(t *testing.T) {
+	body := `{"result":"success","data":[
+		{"account_id":"1","zone":"example.com","record":"example.com","type":"A","value":"192.0.2.1","comment":"","editable":"0"},
+		{"account_id":"1","zone":"example.com","record":"foo.example.com","type":"A","value":"192.0.2.2","comment":"","editable":"1"},
+		{"account_id":"1","zone":"example.com","record":"_acme-challenge.Foo.Example.com","type":"TXT","value":"v","comment":"","editable":"1"},
+		{"account_id":"1","zone":"notexample.com","record":"notexample.com","type":"A","value":"192.0.2.3","comment":"","editable":"0"},
+		{"account_id":"1","zone":"example.org","record":"example.com.example.org","type":"A","value":"192.0.2.4","comment":"","editable":"1"}
+	]}`
+	svr := mockHttpResponse(200, body, nil)
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	for _, zone := range []string{"example.com", "Example.COM."} {
+		records, err := c.ListRecordsForZone(zone)
+		if err != nil {
+			t.Fatalf("Expected ListRecordsForZone not to return error, got %v", err)
+		}
+		var names []string
+		for _, record := range records {
+			names = append(names, record.Record)
+		}
+		expected := []string{"example.com", "foo.example.com", "_acme-challenge.Foo.Example.com"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected records in zone %v to be %v, got %v", zone, expected, names)
+		}
+	}
+}
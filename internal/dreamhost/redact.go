@@ -0,0 +1,52 @@
+package dreamhost
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+var keyParamPattern = regexp.MustCompile(`([?&]key=)[^&\s"]*`)
+
+// redact scrubs the API key from s, both as a key query parameter and anywhere else it appears verbatim.
+func (c *DNSClient) redact(s string) string {
+	s = keyParamPattern.ReplaceAllString(s, "${1}"+redacted)
+	if c.apiKey != "" {
+		s = strings.ReplaceAll(s, c.apiKey, redacted)
+		s = strings.ReplaceAll(s, url.QueryEscape(c.apiKey), redacted)
+	}
+	return s
+}
+
+// redactedError hides the API key from the message of the error it wraps, while keeping the error chain intact for
+// errors.Is and errors.As.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactErr returns err with the API key scrubbed from its message. A *url.Error in the chain has its URL scrubbed
+// too, so that it can be safely logged on its own.
+func (c *DNSClient) redactErr(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = c.redact(urlErr.URL)
+	}
+
+	msg := c.redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err, msg}
+}
@@ -0,0 +1,49 @@
+package dreamhost
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	c, _ := NewClient("s3cr3t+key", nil, "")
+
+	cases := map[string]string{
+		"https://api.dreamhost.com/?cmd=dns-list_records&key=s3cr3t%2Bkey&format=json": "https://api.dreamhost.com/?cmd=dns-list_records&key=REDACTED&format=json",
+		"https://api.dreamhost.com/?key=anotherkey":                                    "https://api.dreamhost.com/?key=REDACTED",
+		"failed with key s3cr3t+key":                                                   "failed with key REDACTED",
+		"nothing to see here":                                                          "nothing to see here",
+	}
+
+	for input, expected := range cases {
+		if actual := c.redact(input); actual != expected {
+			t.Errorf("Expected redact(%v) to be %v, got %v", input, expected, actual)
+		}
+	}
+}
+
+func TestConnectionErrorDoesNotContainApiKey(t *testing.T) {
+	apiKey := "supersecretkey123"
+	svr := mockHttpResponse(200, "invalid", nil)
+	svr.Close()
+
+	c, _ := NewClient(apiKey, nil, svr.URL)
+	c.retryAttempts = 1
+	err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err == nil {
+		t.Fatal("Expected CreateRecord to return error, got nil")
+	}
+	if strings.Contains(err.Error(), apiKey) {
+		t.Errorf("Expected err not to contain the API key, got %v", err.Error())
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("Expected err to wrap a *url.Error, got %v", err)
+	}
+	if strings.Contains(urlErr.Error(), apiKey) {
+		t.Errorf("Expected wrapped err not to contain the API key, got %v", urlErr.Error())
+	}
+}
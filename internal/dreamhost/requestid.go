@@ -0,0 +1,43 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the request ID of each API request, for correlating the client's activity with other logs.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id, which the client sends as the X-Request-ID header of the
+// requests made with it and includes in its logs and errors.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ensureRequestID returns ctx and its request ID, first generating one if ctx doesn't carry one, so that every attempt
+// of a command shares an ID.
+func ensureRequestID(ctx context.Context) (context.Context, string) {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return ctx, id
+	}
+	id := uuid.NewString()
+	return ContextWithRequestID(ctx, id), id
+}
+
+// withRequestID adds the request ID to err, if any.
+func withRequestID(err error, id string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w (request id %v)", err, id)
+}
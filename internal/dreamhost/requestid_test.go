@@ -0,0 +1,57 @@
+package dreamhost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	var header string
+	svr := mockHttpResponse(200, `{"result":"error","data":"no_such_zone"}`, func(r *http.Request) {
+		header = r.Header.Get("X-Request-ID")
+	})
+	defer svr.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithLogger(logger))
+	ctx := ContextWithRequestID(context.Background(), "req-123")
+	err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+
+	if header != "req-123" {
+		t.Errorf("Expected X-Request-ID header to be req-123, got %v", header)
+	}
+	if err == nil || !strings.Contains(err.Error(), "req-123") {
+		t.Errorf("Expected error to include the request id, got %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON log entry, got %v", buf.String())
+	}
+	if entry["request_id"] != "req-123" {
+		t.Errorf("Expected log attribute request_id to be req-123, got %v", entry["request_id"])
+	}
+}
+
+func TestRequestIDGeneratedPerCommand(t *testing.T) {
+	var headers []string
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Request-ID"))
+	})
+	defer svr.Close()
+
+	c, _ := NewClient("apikey123", nil, svr.URL)
+	for i := 0; i < 2; i++ {
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+	}
+	if len(headers) != 2 || len(headers[0]) != 36 || headers[0] == headers[1] {
+		t.Errorf("Expected each command to have a distinct generated UUID, got %v", headers)
+	}
+}
@@ -0,0 +1,150 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const defaultRetryAttempts = 3
+const defaultRetryBaseDelay = 500 * time.Millisecond
+const defaultMaxRetryAfter = 30 * time.Second
+
+// maxBackoffDelay is the longest ExponentialBackoff waits, unless its BaseDelay is longer still.
+const maxBackoffDelay = time.Minute
+
+// retryableError marks an error as transient, i.e. worth retrying. If the server said how long to wait before retrying,
+// after holds that delay.
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func retryable(err error) error {
+	return &retryableError{err: err}
+}
+
+// retryableAfter marks err as transient, to be retried after the delay requested by a Retry-After header received at
+// now.
+func retryableAfter(err error, header string, now time.Time) error {
+	return &retryableError{err: err, after: parseRetryAfter(header, now)}
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// IsRetryable reports whether err is a transient failure that is worth retrying: a connection that was refused, reset
+// or timed out, a throttled response, or a server error. Errors reported by the API, such as an invalid record, and
+// cancellation of the caller's context are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var retryErr *retryableError
+	if errors.As(err, &retryErr) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Backoff decides how long to wait before retrying a request that failed transiently.
+type Backoff interface {
+	// NextDelay returns the delay after the given (1-based) attempt.
+	NextDelay(attempt int) time.Duration
+}
+
+// ExponentialBackoff waits BaseDelay after the first attempt, doubling the delay for every further attempt up to a
+// minute, with the upper half of the delay randomized to spread out retries from concurrent callers. It is the default.
+type ExponentialBackoff struct {
+	BaseDelay time.Duration
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	return backoffDelay(b.BaseDelay, attempt)
+}
+
+// ConstantBackoff waits Delay after every attempt.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+func (b ConstantBackoff) NextDelay(attempt int) time.Duration {
+	return b.Delay
+}
+
+// withRetry calls send up to c.retryAttempts times, for as long as it fails with a retryable error. The delay between
+// attempts is chosen by c.backoff, growing exponentially from c.retryBaseDelay if unset, unless the server asked for a
+// specific delay, which is honored up to c.maxRetryAfter. Cancelling ctx stops any further attempts.
+func (c *DNSClient) withRetry(ctx context.Context, send func() ([]byte, error)) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := send()
+
+		var retryErr *retryableError
+		if err == nil || attempt >= c.retryAttempts || !errors.As(err, &retryErr) {
+			return body, err
+		}
+
+		var delay time.Duration
+		if retryErr.after > 0 && c.maxRetryAfter > 0 {
+			delay = min(retryErr.after, c.maxRetryAfter)
+		} else {
+			var backoff Backoff = ExponentialBackoff{c.retryBaseDelay}
+			if c.backoff != nil {
+				backoff = c.backoff
+			}
+			delay = backoff.NextDelay(attempt)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request aborted: %w (last error: %v)", ctx.Err(), err)
+		case <-c.clock.After(delay):
+		}
+	}
+}
+
+// backoffDelay returns the delay after the given (1-based) attempt: baseDelay doubled for every previous attempt, up to
+// maxBackoffDelay, with the upper half of the delay randomized to spread out retries from concurrent callers.
+func backoffDelay(baseDelay time.Duration, attempt int) time.Duration {
+	if baseDelay <= 0 {
+		return 0
+	}
+	// Doubling stops at the cap, so that many attempts can't overflow the delay
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxBackoffDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, max(baseDelay, maxBackoffDelay))
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, which is either a number of seconds or an
+// HTTP-date, or 0 if the header is absent or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCreateRecordRetriesServerErrors(t *testing.T) {
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	c.retryBaseDelay = time.Millisecond
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 requests, got %v", calls)
+	}
+}
+
+func TestCreateRecordGivesUpAfterRetryAttempts(t *testing.T) {
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(500)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	c.retryAttempts = 4
+	c.retryBaseDelay = time.Millisecond
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+		t.Error("Expected CreateRecord to return error, got nil")
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 requests, got %v", calls)
+	}
+}
+
+func TestCreateRecordDoesNotRetryPermanentErrors(t *testing.T) {
+	cases := map[string]func(http.ResponseWriter){
+		"4xx status": func(w http.ResponseWriter) {
+			w.WriteHeader(403)
+		},
+		"error result": func(w http.ResponseWriter) {
+			_, _ = w.Write([]byte(`{"result":"error","data":"invalid_record"}`))
+		},
+	}
+
+	for name, respond := range cases {
+		var calls int32
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			respond(w)
+		}))
+
+		c, _ := NewClient("testApiKey", nil, svr.URL)
+		c.retryBaseDelay = time.Millisecond
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+			t.Errorf("%v: Expected CreateRecord to return error, got nil", name)
+		}
+		if calls != 1 {
+			t.Errorf("%v: Expected 1 request, got %v", name, calls)
+		}
+		svr.Close()
+	}
+}
+
+func TestCreateRecordRetryStopsWhenContextIsCancelled(t *testing.T) {
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		w.WriteHeader(500)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClient("testApiKey", nil, svr.URL)
+	c.retryBaseDelay = time.Hour
+	err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected CreateRecordContext to return context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 request, got %v", calls)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 1; attempt <= 4; attempt++ {
+		max := base << (attempt - 1)
+		for i := 0; i < 20; i++ {
+			if d := backoffDelay(base, attempt); d < max/2 || d > max {
+				t.Errorf("Expected delay for attempt %v to be within [%v, %v], got %v", attempt, max/2, max, d)
+			}
+		}
+	}
+}
+
+func TestBackoffDelayIsCapped(t *testing.T) {
+	for attempt := 1; attempt <= 100; attempt++ {
+		if d := backoffDelay(500*time.Millisecond, attempt); d <= 0 || d > maxBackoffDelay {
+			t.Errorf("Expected delay for attempt %v to be within (0, %v], got %v", attempt, maxBackoffDelay, d)
+		}
+	}
+	if d := backoffDelay(time.Hour, 100); d < 30*time.Minute || d > time.Hour {
+		t.Errorf("Expected a base delay over the cap to be kept, got %v", d)
+	}
+}
+
+func TestCreateRecordManyRetries(t *testing.T) {
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%2 == 0 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		w.WriteHeader(503)
+	}))
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(100, 500*time.Millisecond))
+	clk := newFakeClock()
+	c.clock = clk
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err == nil {
+		t.Error("Expected CreateRecord to return error, got nil")
+	}
+	if calls != 100 {
+		t.Errorf("Expected 100 requests, got %v", calls)
+	}
+	for i, d := range clk.waits {
+		if d <= 0 || d > maxBackoffDelay {
+			t.Errorf("Expected wait %v to be within (0, %v], got %v", i+1, maxBackoffDelay, d)
+		}
+	}
+}
+
+func TestCreateRecordHonorsRetryAfter(t *testing.T) {
+	var calls int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","data":"record_added"}`))
+	}))
+	defer svr.Close()
+
+	// The backoff would wait an hour, so finishing at all shows Retry-After was used instead, and finishing early shows
+	// it was capped
+	c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(2, time.Hour), WithMaxRetryAfter(50*time.Millisecond))
+	start := time.Now()
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Errorf("Expected to wait the capped 50ms before retrying, took %v", elapsed)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests, got %v", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"soon":                          0,
+		"Tue, 02 Jan 2024 03:04:35 GMT": 30 * time.Second,
+		"Tue, 02 Jan 2024 03:00:00 GMT": 0,
+	}
+	for header, expected := range tests {
+		if actual := parseRetryAfter(header, now); actual != expected {
+			t.Errorf("Expected Retry-After %q to give %v, got %v", header, expected, actual)
+		}
+	}
+}
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil":                {nil, false},
+		"server error":       {retryable(&StatusError{Code: 503}), true},
+		"client error":       {&StatusError{Code: 403}, false},
+		"api error":          {&ApiError{Result: "error", Data: "invalid_record"}, false},
+		"connection refused": {&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		"connection reset":   {fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		"timeout":            {&url.Error{Op: "Get", URL: "https://example.com", Err: timeoutError{}}, true},
+		"cancelled":          {fmt.Errorf("request aborted: %w", context.Canceled), false},
+		"other":              {errors.New("something else"), false},
+	}
+	for name, test := range tests {
+		if actual := IsRetryable(test.err); actual != test.expected {
+			t.Errorf("%v: Expected IsRetryable to be %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestIsRetryableAPIResponses(t *testing.T) {
+	for status, expected := range map[int]bool{429: true, 500: true, 404: false} {
+		svr := mockHttpResponse(status, "", nil)
+		c, _ := NewClientWithOptions("testApiKey", WithBaseURL(svr.URL), WithRetry(1, 0))
+		err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+		if actual := IsRetryable(err); actual != expected {
+			t.Errorf("Expected IsRetryable for status %v to be %v, got %v", status, expected, actual)
+		}
+		svr.Close()
+	}
+}
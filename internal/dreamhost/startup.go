@@ -0,0 +1,56 @@
+package dreamhost
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// startupDelay holds back the requests a client makes when it first starts sending, so that many replicas starting at
+// once don't all hit the API at the same moment.
+type startupDelay struct {
+	max  time.Duration
+	once sync.Once
+	// readyAt is when requests may start, chosen at random when the first request is made.
+	readyAt time.Time
+}
+
+// waitForStartup blocks until the client's startup delay, if any, has passed, or ctx is done.
+func (c *DNSClient) waitForStartup(ctx context.Context) error {
+	if c.startup == nil {
+		return nil
+	}
+	c.startup.once.Do(func() {
+		c.startup.readyAt = c.clock.Now().Add(1 + time.Duration(rand.Int63n(int64(c.startup.max))))
+	})
+
+	delay := c.startup.readyAt.Sub(c.clock.Now())
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("startup delay aborted: %w", ctx.Err())
+	case <-c.clock.After(delay):
+		return nil
+	}
+}
+
+// WithStartupJitter delays the client's first request by a random duration of up to max, to spread out the load when
+// many replicas start at once. Other requests made before the first one is sent wait too. By default there is no
+// delay.
+func WithStartupJitter(max time.Duration) Option {
+	return func(o *options) error {
+		if max < 0 {
+			return fmt.Errorf("startup jitter must not be negative, got %v", max)
+		}
+		if max == 0 {
+			o.client.startup = nil
+		} else {
+			o.client.startup = &startupDelay{max: max}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package dreamhost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStartupJitterDelaysOnlyFirstRequest(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithStartupJitter(time.Second))
+	c.clock = clk
+
+	for i := 0; i < 3; i++ {
+		if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+	}
+	if len(clk.waits) != 1 {
+		t.Fatalf("Expected a single startup wait, got %v", clk.waits)
+	}
+	if clk.waits[0] <= 0 || clk.waits[0] > time.Second {
+		t.Errorf("Expected startup wait to be within (0, 1s], got %v", clk.waits[0])
+	}
+}
+
+func TestStartupJitterRespectsContext(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+		t.Error("Expected no request to be made")
+	})
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithStartupJitter(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := c.CreateRecordContext(ctx, DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected CreateRecordContext to return context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestNoStartupJitterByDefault(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	clk := newFakeClock()
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL))
+	c.clock = clk
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if len(clk.waits) != 0 {
+		t.Errorf("Expected no waits, got %v", clk.waits)
+	}
+}
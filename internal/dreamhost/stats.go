@@ -0,0 +1,25 @@
+package dreamhost
+
+import "time"
+
+// Stats receives the timing of each API command, e.g. to forward it to a metrics system.
+type Stats interface {
+	// ObserveRequest is called after each command, with how long it took including retries, and the error it failed
+	// with, if any.
+	ObserveRequest(cmd string, d time.Duration, err error)
+}
+
+// observeRequest reports a command that started at start to the Stats, if any.
+func (c *DNSClient) observeRequest(cmd string, start time.Time, err error) {
+	if c.stats != nil {
+		c.stats.ObserveRequest(cmd, time.Since(start), err)
+	}
+}
+
+// WithStats sets the Stats that the client reports each command's timing to. By default timings aren't reported.
+func WithStats(stats Stats) Option {
+	return func(o *options) error {
+		o.client.stats = stats
+		return nil
+	}
+}
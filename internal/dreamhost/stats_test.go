@@ -0,0 +1,52 @@
+package dreamhost
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type observation struct {
+	cmd string
+	d   time.Duration
+	err error
+}
+
+// recordingStats records each observation it receives.
+type recordingStats struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (s *recordingStats) ObserveRequest(cmd string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observations = append(s.observations, observation{cmd, d, err})
+}
+
+func TestWithStats(t *testing.T) {
+	svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, nil)
+	defer svr.Close()
+
+	stats := &recordingStats{}
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithStats(stats))
+	if err := c.CreateRecord(DNSRecordValue{Name: "example.com", RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+		t.Errorf("Expected CreateRecord not to return error, got %v", err)
+	}
+	if _, err := c.ListRecords(); err == nil {
+		t.Error("Expected ListRecords of a scalar response to return error, got nil")
+	}
+
+	if len(stats.observations) != 2 {
+		t.Fatalf("Expected 2 observations, got %+v", stats.observations)
+	}
+	for i, cmd := range []string{"dns-add_record", "dns-list_records"} {
+		o := stats.observations[i]
+		if o.cmd != cmd || o.d < 0 {
+			t.Errorf("Expected observation of %v with a non-negative duration, got %+v", cmd, o)
+		}
+	}
+	if stats.observations[0].err != nil || stats.observations[1].err == nil {
+		t.Errorf("Expected only the second observation to have an error, got %+v", stats.observations)
+	}
+}
@@ -0,0 +1,49 @@
+package dreamhost
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+)
+
+// UniqueIDGenerator chooses the unique_id of a create when the caller doesn't supply one.
+type UniqueIDGenerator interface {
+	UniqueID(r DNSRecordValue) string
+}
+
+// ContentHashUniqueIDs derives each unique_id from the record's content with DeriveUniqueID, so that every create of
+// the same record shares an id and is deduplicated. It is the generator used by WithAutoUniqueID.
+type ContentHashUniqueIDs struct{}
+
+func (ContentHashUniqueIDs) UniqueID(r DNSRecordValue) string {
+	return DeriveUniqueID(r)
+}
+
+// sequenceUniqueIDs numbers unique_ids in sequence after a random prefix, so that ids don't repeat within a process or
+// collide with those of other processes.
+type sequenceUniqueIDs struct {
+	prefix string
+	seq    atomic.Uint64
+}
+
+// NewSequenceUniqueIDs returns a UniqueIDGenerator that gives every create a distinct unique_id, so that only retries
+// of the same request are deduplicated, rather than every create of the same record. It is safe for concurrent use.
+func NewSequenceUniqueIDs() UniqueIDGenerator {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return &sequenceUniqueIDs{prefix: hex.EncodeToString(b[:])}
+}
+
+func (g *sequenceUniqueIDs) UniqueID(DNSRecordValue) string {
+	return fmt.Sprintf("%v-%v", g.prefix, g.seq.Add(1))
+}
+
+// WithUniqueIDGenerator makes CreateRecord take a unique_id from generator when the caller doesn't supply one. A nil
+// generator, the default, sends no unique_id.
+func WithUniqueIDGenerator(generator UniqueIDGenerator) Option {
+	return func(o *options) error {
+		o.client.uniqueIds = generator
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package dreamhost
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestContentHashUniqueIDsAreDeterministic(t *testing.T) {
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	var g ContentHashUniqueIDs
+	if g.UniqueID(r) != g.UniqueID(r) || g.UniqueID(r) != DeriveUniqueID(r) {
+		t.Errorf("Expected the same record to always get the id %v, got %v", DeriveUniqueID(r), g.UniqueID(r))
+	}
+}
+
+func TestSequenceUniqueIDsAreUnique(t *testing.T) {
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	g := NewSequenceUniqueIDs()
+	other := NewSequenceUniqueIDs()
+	if g.UniqueID(r) == other.UniqueID(r) {
+		t.Error("Expected generators to use distinct prefixes")
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ids := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := g.UniqueID(r)
+			mu.Lock()
+			defer mu.Unlock()
+			ids[id] = true
+		}()
+	}
+	wg.Wait()
+	if len(ids) != 50 {
+		t.Errorf("Expected 50 distinct ids, got %v", len(ids))
+	}
+}
+
+func TestWithUniqueIDGenerator(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithUniqueIDGenerator(NewSequenceUniqueIDs()))
+	r := DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}
+	for i := 0; i < 2; i++ {
+		if err := c.CreateRecord(r, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+	}
+
+	first, second := svr.requests[0].URL.Query().Get("unique_id"), svr.requests[1].URL.Query().Get("unique_id")
+	if first == "" || first == second {
+		t.Errorf("Expected each create to get a distinct unique_id, got %q and %q", first, second)
+	}
+}
+
+func TestCreateRecordResultReturnsUniqueID(t *testing.T) {
+	svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"record_added"}` })
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithUniqueIDGenerator(NewSequenceUniqueIDs()))
+	resp, err := c.CreateRecordResult(DNSRecordValue{Name: "_acme-challenge.example.com", RecordType: "TXT", Value: "testValue"}, "")
+	if err != nil {
+		t.Fatalf("Expected CreateRecordResult not to return error, got %v", err)
+	}
+	if sent := svr.requests[0].URL.Query().Get("unique_id"); sent == "" || resp.UniqueID != sent {
+		t.Errorf("Expected the returned unique_id to be the one sent, %q, got %q", sent, resp.UniqueID)
+	}
+}
@@ -0,0 +1,99 @@
+package dreamhost
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ZoneFromFQDN guesses the DreamHost zone for a record by computing the registrable domain of fqdn using the public
+// suffix list, e.g. "_acme-challenge.www.example.co.uk." becomes "example.co.uk". No API call is made, so the result
+// is only a guess: a zone delegated below the registrable domain will not be found this way.
+func ZoneFromFQDN(fqdn string) (string, error) {
+	name := normalizeName(fqdn)
+	if name == "" {
+		return "", fmt.Errorf("cannot derive zone from empty name")
+	}
+
+	zone, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive zone for %v: %w", fqdn, err)
+	}
+	return zone, nil
+}
+
+// KeyResolver selects the API key to use for a record, for setups where zones are managed by different DreamHost
+// accounts. KeyFor returns false if the record should use the client's default key.
+type KeyResolver interface {
+	KeyFor(name string) (string, bool)
+}
+
+// ZoneKeys is a KeyResolver mapping zones to API keys. A record uses the key of the longest zone that it is in, e.g.
+// with keys for "example.com" and "dev.example.com", "_acme-challenge.www.dev.example.com" uses the latter.
+type ZoneKeys map[string]string
+
+// KeyFor returns the key of the longest zone containing name.
+func (z ZoneKeys) KeyFor(name string) (string, bool) {
+	key, best := "", ""
+	for zone, zoneKey := range z {
+		if inZone(name, zone) && len(normalizeName(zone)) > len(best) {
+			key, best = zoneKey, normalizeName(zone)
+		}
+	}
+	return key, best != ""
+}
+
+// inZone reports whether name is zone itself or a subdomain of it. Both are compared case-insensitively and without
+// any trailing dot.
+func inZone(name string, zone string) bool {
+	name, zone = normalizeName(name), normalizeName(zone)
+	if zone == "" {
+		return false
+	}
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// WithKeyResolver makes the client pick the API key for each record using resolver, falling back to the key passed to
+// NewClientWithOptions for records it doesn't match and for account-wide commands such as ListRecords.
+func WithKeyResolver(resolver KeyResolver) Option {
+	return func(o *options) error {
+		o.client.keyResolver = resolver
+		return nil
+	}
+}
+
+// checkAllowedZone returns an error matching ErrZoneNotAllowed if zones are restricted with WithAllowedZones and r
+// isn't in any of them.
+func (c *DNSClient) checkAllowedZone(r DNSRecordValue) error {
+	if len(c.allowedZones) == 0 {
+		return nil
+	}
+	for _, zone := range c.allowedZones {
+		if inZone(r.Name, zone) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v record %v is not in any of the zones %v", ErrZoneNotAllowed, r.RecordType, r.Name,
+		strings.Join(c.allowedZones, ", "))
+}
+
+// WithAllowedZones restricts the records the client creates and deletes to the given zones and their subdomains, as a
+// guard against a misconfiguration writing to the wrong zone. Other records are rejected with an error matching
+// ErrZoneNotAllowed, without contacting the API. By default every zone is allowed.
+func WithAllowedZones(zones ...string) Option {
+	return func(o *options) error {
+		for _, zone := range zones {
+			if normalizeName(zone) == "" {
+				return errors.New("allowed zones must not be empty")
+			}
+		}
+		o.client.allowedZones = zones
+		return nil
+	}
+}
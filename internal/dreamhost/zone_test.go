@@ -0,0 +1,137 @@
+package dreamhost
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestZoneFromFQDN(t *testing.T) {
+	cases := map[string]string{
+		"example.com":                           "example.com",
+		"_acme-challenge.example.com.":          "example.com",
+		"_acme-challenge.WWW.Example.COM.":      "example.com",
+		"_acme-challenge.www.example.co.uk.":    "example.co.uk",
+		"_acme-challenge.foo.example.com.au":    "example.com.au",
+		"_acme-challenge.example.pvt.k12.ma.us": "example.pvt.k12.ma.us",
+	}
+
+	for fqdn, expected := range cases {
+		actual, err := ZoneFromFQDN(fqdn)
+		if err != nil {
+			t.Errorf("Expected ZoneFromFQDN(%v) not to return error, got %v", fqdn, err)
+		}
+		if actual != expected {
+			t.Errorf("Expected ZoneFromFQDN(%v) to be %v, got %v", fqdn, expected, actual)
+		}
+	}
+}
+
+func TestZoneFromFQDNReturnsErrorForPublicSuffix(t *testing.T) {
+	for _, fqdn := range []string{"", ".", "co.uk.", "com"} {
+		if _, err := ZoneFromFQDN(fqdn); err == nil {
+			t.Errorf("Expected ZoneFromFQDN(%v) to return error, got nil", fqdn)
+		}
+	}
+}
+
+func TestZoneKeysLongestSuffixMatch(t *testing.T) {
+	keys := ZoneKeys{
+		"example.com":     "key1",
+		"dev.example.com": "key2",
+		"example.org.":    "key3",
+	}
+
+	cases := map[string]string{
+		"example.com":                          "key1",
+		"_acme-challenge.www.example.com":      "key1",
+		"_acme-challenge.dev.example.com":      "key2",
+		"_acme-challenge.www.DEV.example.com.": "key2",
+		"_acme-challenge.example.org":          "key3",
+	}
+	for name, expected := range cases {
+		key, ok := keys.KeyFor(name)
+		if !ok || key != expected {
+			t.Errorf("Expected KeyFor(%v) to be %v, got %v (%v)", name, expected, key, ok)
+		}
+	}
+
+	for _, name := range []string{"notexample.com", "example.net", "com"} {
+		if key, ok := keys.KeyFor(name); ok {
+			t.Errorf("Expected KeyFor(%v) not to match, got %v", name, key)
+		}
+	}
+}
+
+func TestCreateRecordUsesZoneKey(t *testing.T) {
+	cases := map[string]string{
+		"_acme-challenge.example.com": "zoneKey",
+		"_acme-challenge.example.net": "defaultKey",
+	}
+
+	for name, expected := range cases {
+		svr := mockHttpResponse(200, `{"result":"success","data":"record_added"}`, func(r *http.Request) {
+			if actual := r.URL.Query().Get("key"); actual != expected {
+				t.Errorf("Expected key for %v to be %v, got %v", name, expected, actual)
+			}
+		})
+
+		c, _ := NewClientWithOptions("defaultKey", WithBaseURL(svr.URL), WithKeyResolver(ZoneKeys{"example.com": "zoneKey"}))
+		if err := c.CreateRecord(DNSRecordValue{Name: name, RecordType: "TXT", Value: "testValue"}, ""); err != nil {
+			t.Errorf("Expected CreateRecord not to return error, got %v", err)
+		}
+		svr.Close()
+	}
+}
+
+func TestWithAllowedZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		zones   []string
+		record  string
+		allowed bool
+	}{
+		{"in zone", []string{"example.org", "example.com"}, "_acme-challenge.www.Example.com.", true},
+		{"out of zone", []string{"example.org", "example.com"}, "_acme-challenge.notexample.com", false},
+		{"no allowlist", nil, "_acme-challenge.notexample.com", true},
+	}
+	for _, test := range tests {
+		svr := newRecordingServer(func(r *http.Request) string { return `{"result":"success","data":"ok"}` })
+
+		c, _ := NewClientWithOptions("apikey123", WithBaseURL(svr.URL), WithAllowedZones(test.zones...))
+		r := DNSRecordValue{Name: test.record, RecordType: "TXT", Value: "testValue"}
+		for op, err := range map[string]error{"CreateRecord": c.CreateRecord(r, ""), "DeleteRecord": c.DeleteRecord(r, "")} {
+			if test.allowed && err != nil {
+				t.Errorf("%v: Expected %v not to return error, got %v", test.name, op, err)
+			}
+			if !test.allowed && (!errors.Is(err, ErrZoneNotAllowed) || !strings.Contains(err.Error(), "example.org, example.com")) {
+				t.Errorf("%v: Expected %v to return ErrZoneNotAllowed naming the zones, got %v", test.name, op, err)
+			}
+		}
+		if cmds := svr.commands(); test.allowed != (len(cmds) == 2) {
+			t.Errorf("%v: Expected requests only for allowed records, got %v", test.name, cmds)
+		}
+		svr.Close()
+	}
+}
+
+func TestReplaceRecordListsWithZoneKey(t *testing.T) {
+	svr := newListingServer()
+	defer svr.Close()
+
+	c, _ := NewClientWithOptions("defaultKey", WithBaseURL(svr.URL), WithKeyResolver(ZoneKeys{"other.org": "otherKey"}))
+	if err := c.ReplaceRecord(DNSRecordValue{Name: "_acme-challenge.other.org", RecordType: "TXT", Value: "newValue"}, ""); err != nil {
+		t.Errorf("Expected ReplaceRecord not to return error, got %v", err)
+	}
+
+	if expected := "[dns-list_records dns-add_record newValue]"; fmt.Sprint(svr.commands()) != expected {
+		t.Errorf("Expected commands %v, got %v", expected, svr.commands())
+	}
+	for _, r := range svr.requests {
+		if key := r.URL.Query().Get("key"); key != "otherKey" {
+			t.Errorf("Expected %v to be sent with key otherKey, got %v", r.URL.Query().Get("cmd"), key)
+		}
+	}
+}
@@ -1,31 +1,79 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/client-go/rest"
 
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	"github.com/nprzy/cert-manager-webhook-dreamhost/internal/dreamhost"
 )
 
 var GroupName = os.Getenv("GROUP_NAME")
 
+// MaxConcurrentOperations bounds how many Present/CleanUp calls may talk to
+// DreamHost at once. It can be overridden with the MAX_CONCURRENT_OPERATIONS
+// environment variable.
+var MaxConcurrentOperations = os.Getenv("MAX_CONCURRENT_OPERATIONS")
+
+const defaultMaxConcurrentOperations = 10
+
+// APIKey, when set by the DREAMHOST_API_KEY environment variable, is checked
+// against the DreamHost API when the webhook starts, so that a wrong key or an
+// unreachable API fails the deployment rather than the first challenge.
+var APIKey = os.Getenv("DREAMHOST_API_KEY")
+
+// HealthCheckTimeout bounds the startup check of APIKey. It can be overridden
+// with the HEALTH_CHECK_TIMEOUT environment variable, e.g. "30s".
+var HealthCheckTimeout = os.Getenv("HEALTH_CHECK_TIMEOUT")
+
+const defaultHealthCheckTimeout = 10 * time.Second
+
 func main() {
 	if GroupName == "" {
 		panic("GROUP_NAME must be specified")
 	}
 
+	maxConcurrency := defaultMaxConcurrentOperations
+	if MaxConcurrentOperations != "" {
+		n, err := strconv.Atoi(MaxConcurrentOperations)
+		if err != nil || n < 1 {
+			panic("MAX_CONCURRENT_OPERATIONS must be a positive integer")
+		}
+		maxConcurrency = n
+	}
+
+	solver := newSolver(maxConcurrency)
+	solver.apiKey = APIKey
+	if HealthCheckTimeout != "" {
+		d, err := time.ParseDuration(HealthCheckTimeout)
+		if err != nil || d <= 0 {
+			panic("HEALTH_CHECK_TIMEOUT must be a positive duration")
+		}
+		solver.healthCheckTimeout = d
+	}
+
 	// This will register our custom DNS provider with the webhook serving
 	// library, making it available as an API under the provided GroupName.
 	// You can register multiple DNS provider implementations with a single
 	// webhook, where the Name() method will be used to disambiguate between
 	// the different implementations.
 	cmd.RunWebhookServer(GroupName,
-		&dreamHostDnsProviderSolver{},
+		solver,
 	)
 }
 
@@ -41,6 +89,49 @@ type dreamHostDnsProviderSolver struct {
 	// 4. ensure your webhook's service account has the required RBAC role
 	//    assigned to it for interacting with the Kubernetes APIs you need.
 	//client kubernetes.Clientset
+
+	// sem bounds the number of Present/CleanUp calls in flight, since
+	// cert-manager may call them concurrently for many challenges.
+	sem chan struct{}
+	// stopCh is closed when the webhook is shutting down.
+	stopCh <-chan struct{}
+
+	// apiKey, if set, is used to check connectivity to DreamHost in
+	// Initialize, and that client is kept in client. Tests may set client to
+	// a fake instead.
+	apiKey             string
+	baseUrl            string
+	healthCheckTimeout time.Duration
+	client             dreamhost.DNSProvider
+}
+
+// newSolver returns a solver that allows at most maxConcurrency Present and
+// CleanUp calls to run at once.
+func newSolver(maxConcurrency int) *dreamHostDnsProviderSolver {
+	return &dreamHostDnsProviderSolver{
+		sem:                make(chan struct{}, maxConcurrency),
+		healthCheckTimeout: defaultHealthCheckTimeout,
+	}
+}
+
+// acquire waits for a free slot in the semaphore, giving up if the webhook is
+// shut down while waiting.
+func (c *dreamHostDnsProviderSolver) acquire() error {
+	if c.sem == nil {
+		return nil
+	}
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-c.stopCh:
+		return errors.New("webhook is shutting down")
+	}
+}
+
+func (c *dreamHostDnsProviderSolver) release() {
+	if c.sem != nil {
+		<-c.sem
+	}
 }
 
 // dreamHostDnsProviderConfig is a structure that is used to decode into when
@@ -58,13 +149,10 @@ type dreamHostDnsProviderSolver struct {
 // be used by your provider here, you should reference a Kubernetes Secret
 // resource and fetch these credentials using a Kubernetes clientset.
 type dreamHostDnsProviderConfig struct {
-	// Change the two fields below according to the format of the configuration
-	// to be decoded.
 	// These fields will be set by users in the
 	// `issuer.spec.acme.dns01.providers.webhook.config` field.
 
-	//Email           string `json:"email"`
-	//APIKeySecretRef v1alpha1.SecretKeySelector `json:"apiKeySecretRef"`
+	APIKeySecretRef cmmeta.SecretKeySelector `json:"apiKeySecretRef"`
 }
 
 // Name is used as the name for this DNS solver when referencing it on the ACME
@@ -83,6 +171,11 @@ func (c *dreamHostDnsProviderSolver) Name() string {
 // cert-manager itself will later perform a self check to ensure that the
 // solver has correctly configured the DNS provider.
 func (c *dreamHostDnsProviderSolver) Present(ch *v1alpha1.ChallengeRequest) error {
+	if err := c.acquire(); err != nil {
+		return err
+	}
+	defer c.release()
+
 	cfg, err := loadConfig(ch.Config)
 	if err != nil {
 		return err
@@ -102,6 +195,11 @@ func (c *dreamHostDnsProviderSolver) Present(ch *v1alpha1.ChallengeRequest) erro
 // This is in order to facilitate multiple DNS validations for the same domain
 // concurrently.
 func (c *dreamHostDnsProviderSolver) CleanUp(ch *v1alpha1.ChallengeRequest) error {
+	if err := c.acquire(); err != nil {
+		return err
+	}
+	defer c.release()
+
 	// TODO: add code that deletes a record from the DNS provider's console
 	return nil
 }
@@ -116,6 +214,8 @@ func (c *dreamHostDnsProviderSolver) CleanUp(ch *v1alpha1.ChallengeRequest) erro
 // The stopCh can be used to handle early termination of the webhook, in cases
 // where a SIGTERM or similar signal is sent to the webhook process.
 func (c *dreamHostDnsProviderSolver) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
+	c.stopCh = stopCh
+
 	///// UNCOMMENT THE BELOW CODE TO MAKE A KUBERNETES CLIENTSET AVAILABLE TO
 	///// YOUR CUSTOM DNS PROVIDER
 
@@ -127,20 +227,72 @@ func (c *dreamHostDnsProviderSolver) Initialize(kubeClientConfig *rest.Config, s
 	//c.client = cl
 
 	///// END OF CODE TO MAKE KUBERNETES CLIENTSET AVAILABLE
+
+	if c.apiKey == "" {
+		return nil
+	}
+	client, err := dreamhost.NewClientWithOptions(c.apiKey, dreamhost.WithBaseURL(c.baseUrl))
+	if err != nil {
+		return fmt.Errorf("failed to create DreamHost client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.healthCheckTimeout)
+	defer cancel()
+	if err := client.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("DreamHost API key check failed, verify the key and network access to the API: %w", err)
+	}
+	c.client = client
 	return nil
 }
 
 // loadConfig is a small helper function that decodes JSON configuration into
 // the typed config struct.
+// Unknown fields are rejected so that a typo in the Issuer config (e.g.
+// `apikeySecretRef`) fails loudly instead of being silently ignored.
 func loadConfig(cfgJSON *extapi.JSON) (dreamHostDnsProviderConfig, error) {
 	cfg := dreamHostDnsProviderConfig{}
 	// handle the 'base case' where no configuration has been provided
 	if cfgJSON == nil {
 		return cfg, nil
 	}
-	if err := json.Unmarshal(cfgJSON.Raw, &cfg); err != nil {
+	if err := checkConfigFields(cfgJSON.Raw); err != nil {
+		return cfg, fmt.Errorf("error decoding solver config: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(cfgJSON.Raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
 		return cfg, fmt.Errorf("error decoding solver config: %v", err)
 	}
 
 	return cfg, nil
 }
+
+// checkConfigFields returns an error naming the first top-level field that does
+// not exactly match a field of dreamHostDnsProviderConfig. encoding/json matches
+// field names case-insensitively, so DisallowUnknownFields alone would accept
+// `apikeySecretRef`.
+func checkConfigFields(raw []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	t := reflect.TypeOf(dreamHostDnsProviderConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("json: unknown field %q", name)
+		}
+	}
+	return nil
+}
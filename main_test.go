@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
 	acmetest "github.com/cert-manager/cert-manager/test/acme"
+	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	"github.com/nprzy/cert-manager-webhook-dreamhost/example"
+	"github.com/nprzy/cert-manager-webhook-dreamhost/internal/dreamhost"
 )
 
 var (
@@ -39,3 +49,245 @@ func TestRunsSuite(t *testing.T) {
 	fixture.RunExtended(t)
 
 }
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := loadConfig(&extapi.JSON{Raw: []byte(`{"apiKeySecretRef":{"name":"dreamhost","key":"api-key"}}`)})
+	if err != nil {
+		t.Errorf("expected loadConfig err to be nil, got %v", err)
+	}
+	if cfg.APIKeySecretRef.Name != "dreamhost" {
+		t.Errorf("expected secret name to be dreamhost, got %v", cfg.APIKeySecretRef.Name)
+	}
+	if cfg.APIKeySecretRef.Key != "api-key" {
+		t.Errorf("expected secret key to be api-key, got %v", cfg.APIKeySecretRef.Key)
+	}
+}
+
+func TestLoadConfigWithUnknownField(t *testing.T) {
+	expectedErrContent := `unknown field "apikeySecretRef"`
+
+	_, err := loadConfig(&extapi.JSON{Raw: []byte(`{"apikeySecretRef":{"name":"dreamhost","key":"api-key"}}`)})
+	if err == nil {
+		t.Error("expected loadConfig to return err, got nil")
+	} else if !strings.Contains(err.Error(), expectedErrContent) {
+		t.Errorf("expected err to contain %v, but was %v instead", expectedErrContent, err.Error())
+	}
+}
+
+func TestSolverConcurrencyLimit(t *testing.T) {
+	const limit = 3
+	solver := newSolver(limit)
+
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := solver.acquire(); err != nil {
+				t.Errorf("expected acquire err to be nil, got %v", err)
+				return
+			}
+			defer solver.release()
+
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > limit {
+		t.Errorf("expected at most %v operations in flight, got %v", limit, maxInFlight)
+	}
+}
+
+func TestSolverConcurrencyLimitGatesPresentAndCleanUp(t *testing.T) {
+	solver := newSolver(1)
+	if err := solver.acquire(); err != nil {
+		t.Fatalf("expected acquire err to be nil, got %v", err)
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_ = solver.Present(&v1alpha1.ChallengeRequest{})
+		done <- struct{}{}
+	}()
+	go func() {
+		_ = solver.CleanUp(&v1alpha1.ChallengeRequest{})
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Present and CleanUp to wait for a free slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	solver.release()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected Present and CleanUp to finish once a slot was released")
+		}
+	}
+}
+
+func TestSolverConcurrencyLimitStopsWaitingOnShutdown(t *testing.T) {
+	stopCh := make(chan struct{})
+	solver := newSolver(1)
+	if err := solver.Initialize(nil, stopCh); err != nil {
+		t.Fatalf("expected Initialize err to be nil, got %v", err)
+	}
+	if err := solver.acquire(); err != nil {
+		t.Fatalf("expected acquire err to be nil, got %v", err)
+	}
+
+	close(stopCh)
+	if err := solver.Present(&v1alpha1.ChallengeRequest{}); err == nil {
+		t.Error("expected Present to return err after shutdown, got nil")
+	}
+}
+
+func TestInitializeChecksAPIKey(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "goodKey" {
+			_, _ = w.Write([]byte(`{"result":"error","data":"invalid_api_key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","data":[]}`))
+	}))
+	defer svr.Close()
+
+	solver := newSolver(1)
+	solver.apiKey = "goodKey"
+	solver.baseUrl = svr.URL
+	if err := solver.Initialize(nil, nil); err != nil {
+		t.Errorf("expected Initialize err to be nil, got %v", err)
+	}
+	if solver.client == nil {
+		t.Error("expected Initialize to keep the DreamHost client")
+	}
+
+	solver = newSolver(1)
+	solver.apiKey = "badKey"
+	solver.baseUrl = svr.URL
+	if err := solver.Initialize(nil, nil); err == nil {
+		t.Error("expected Initialize to return err for a bad key, got nil")
+	} else if !strings.Contains(err.Error(), "DreamHost API key check failed") {
+		t.Errorf("expected err to explain the key check failed, got %v", err)
+	}
+}
+
+func TestInitializeHealthCheckTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer svr.Close()
+	defer close(release)
+
+	solver := newSolver(1)
+	solver.apiKey = "goodKey"
+	solver.baseUrl = svr.URL
+	solver.healthCheckTimeout = 50 * time.Millisecond
+	start := time.Now()
+	if err := solver.Initialize(nil, nil); err == nil {
+		t.Error("expected Initialize to return err for an unresponsive API, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected Initialize to give up after the health check timeout, took %v", elapsed)
+	}
+}
+
+// fakeDNSProvider is an in-memory dreamhost.DNSProvider.
+type fakeDNSProvider struct {
+	mu      sync.Mutex
+	records []dreamhost.DNSRecordValue
+}
+
+var _ dreamhost.DNSProvider = (*fakeDNSProvider)(nil)
+
+func (f *fakeDNSProvider) CreateRecord(r dreamhost.DNSRecordValue, uniqueId string) error {
+	return f.CreateRecordContext(context.Background(), r, uniqueId)
+}
+
+func (f *fakeDNSProvider) CreateRecordContext(ctx context.Context, r dreamhost.DNSRecordValue, uniqueId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, r)
+	return nil
+}
+
+func (f *fakeDNSProvider) DeleteRecord(r dreamhost.DNSRecordValue, uniqueId string) error {
+	return f.DeleteRecordContext(context.Background(), r, uniqueId)
+}
+
+func (f *fakeDNSProvider) DeleteRecordContext(ctx context.Context, r dreamhost.DNSRecordValue, uniqueId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, record := range f.records {
+		if record == r {
+			f.records = append(f.records[:i], f.records[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeDNSProvider) ListRecords() ([]dreamhost.DNSRecord, error) {
+	return f.ListRecordsContext(context.Background())
+}
+
+func (f *fakeDNSProvider) ListRecordsContext(ctx context.Context) ([]dreamhost.DNSRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var records []dreamhost.DNSRecord
+	for _, r := range f.records {
+		records = append(records, dreamhost.DNSRecord{Record: r.Name, Type: r.RecordType, Value: r.Value, Editable: true})
+	}
+	return records, nil
+}
+
+func (f *fakeDNSProvider) GetRecord(r dreamhost.DNSRecordValue) (*dreamhost.DNSRecord, bool, error) {
+	return f.GetRecordContext(context.Background(), r)
+}
+
+func (f *fakeDNSProvider) GetRecordContext(ctx context.Context, r dreamhost.DNSRecordValue) (*dreamhost.DNSRecord, bool, error) {
+	records, _ := f.ListRecordsContext(ctx)
+	for i, record := range records {
+		if record.Record == r.Name && record.Type == r.RecordType && record.Value == r.Value {
+			return &records[i], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func TestInitializeKeepsInjectedProvider(t *testing.T) {
+	fake := &fakeDNSProvider{}
+	solver := newSolver(1)
+	solver.client = fake
+	if err := solver.Initialize(nil, nil); err != nil {
+		t.Errorf("expected Initialize err to be nil, got %v", err)
+	}
+	if solver.client != fake {
+		t.Error("expected Initialize without an API key to keep the injected provider")
+	}
+}
+
+func TestInitializeWithoutAPIKey(t *testing.T) {
+	solver := newSolver(1)
+	if err := solver.Initialize(nil, nil); err != nil {
+		t.Errorf("expected Initialize err to be nil, got %v", err)
+	}
+	if solver.client != nil {
+		t.Error("expected Initialize not to create a DreamHost client without an API key")
+	}
+}